@@ -0,0 +1,316 @@
+// Command logview filters and pretty prints the JSON lines written by
+// log.GoKitIOLogger. It reads the files given as arguments, gzip
+// compressed or not, or stdin if there are none. Lines that are not JSON
+// are printed as they are.
+//
+// Usage:
+//
+//	logview [flags] [file ...]
+//
+// Examples:
+//
+//	logview -level error app.log app-*.log.gz
+//	logview -since 1h -field logger=api -message 'timeout|refused' app.log
+//	tail -n 100 app.log | logview -field user_id=3
+//	logview --follow app.log
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+)
+
+const followInterval = 250 * time.Millisecond
+
+// fieldFilters collects the -field flags.
+type fieldFilters map[string]string
+
+func (ff fieldFilters) String() string {
+	parts := make([]string, 0, len(ff))
+	for k, v := range ff {
+		parts = append(parts, k+"="+v)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (ff fieldFilters) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	ff[key] = value
+
+	return nil
+}
+
+type config struct {
+	filter filter
+	follow bool
+	color  bool
+}
+
+func main() {
+	// an interrupt ends -follow cleanly, with the output flushed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "logview: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run reads the files in args, or stdin. Following a file stops when ctx is
+// done.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("logview", flag.ContinueOnError)
+	level := fs.String("level", "", "minimum level: debug, info or error")
+	since := fs.String("since", "", "only lines at or after this time, RFC 3339 or a duration like 1h")
+	until := fs.String("until", "", "only lines before this time, RFC 3339 or a duration like 10m")
+	message := fs.String("message", "", "only lines with a message that matches this regular expression")
+	fields := fieldFilters{}
+	fs.Var(fields, "field", "only lines with this key=value, can be repeated; nested keys are dotted")
+	follow := fs.Bool("follow", false, "keep reading the last file as it grows")
+	fs.BoolVar(follow, "f", false, "short for -follow")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "", "disable colors")
+	forceColor := fs.Bool("color", false, "use colors, even when stdout is not a terminal")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conf := config{
+		filter: filter{
+			fields: fields,
+		},
+		follow: *follow,
+		color:  *forceColor || (!*noColor && isTerminal(stdout)),
+	}
+	if *level != "" {
+		l, err := log.ParseLogLevel(*level)
+		if err != nil {
+			return err
+		}
+		conf.filter.level = l
+	}
+	now := time.Now()
+	var err error
+	if conf.filter.since, err = parseTime(*since, now); err != nil {
+		return fmt.Errorf("invalid -since: %w", err)
+	}
+	if conf.filter.until, err = parseTime(*until, now); err != nil {
+		return fmt.Errorf("invalid -until: %w", err)
+	}
+	if *message != "" {
+		if conf.filter.message, err = regexp.Compile(*message); err != nil {
+			return fmt.Errorf("invalid -message: %w", err)
+		}
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	p := &printer{
+		out:    out,
+		filter: conf.filter,
+		color:  conf.color,
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		// stdin is read until it is closed, following is implied
+		return p.read(stdin)
+	}
+	for i, name := range files {
+		last := i == len(files)-1
+		if err := p.readFile(ctx, name, last && conf.follow); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseTime accepts a time in RFC 3339, a date and time without zone or a
+// date, all in local time, or a duration that is subtracted from now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateTime, "2006-01-02T15:04:05", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown time format: %q", s)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *printer) readFile(ctx context.Context, name string, follow bool) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	if isGzip(br) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		defer zr.Close()
+		// compressed files are rotated, they do not grow anymore
+		return p.read(zr)
+	}
+	if !follow {
+		return p.read(br)
+	}
+
+	return p.follow(ctx, name, f, br)
+}
+
+func isGzip(br *bufio.Reader) bool {
+	magic, err := br.Peek(2)
+
+	return err == nil && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+// read prints the lines of r. The output is flushed whenever no more input
+// is buffered, so that lines from a pipe show up right away.
+func (p *printer) read(r io.Reader) error {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if err := p.print(line); err != nil {
+				return err
+			}
+		}
+		if br.Buffered() == 0 {
+			p.out.Flush()
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// follow keeps reading name after the end is reached, like tail -f. When
+// the file is replaced, as happens on rotation, or truncated, the new
+// content is read from the start. It stops when ctx is done.
+func (p *printer) follow(ctx context.Context, name string, f *os.File, br *bufio.Reader) error {
+	defer func() {
+		f.Close()
+	}()
+
+	var partial []byte
+	var offset int64
+	for {
+		line, err := br.ReadBytes('\n')
+		offset += int64(len(line))
+		if err == nil {
+			if err := p.print(append(partial, line...)); err != nil {
+				return err
+			}
+			partial = nil
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+		partial = append(partial, line...)
+
+		p.out.Flush()
+		select {
+		case <-ctx.Done():
+			if len(partial) > 0 {
+				return p.print(partial)
+			}
+			return nil
+		case <-time.After(followInterval):
+		}
+
+		current, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		latest, err := os.Stat(name)
+		if err != nil {
+			// in between rotation, try again later
+			continue
+		}
+		if os.SameFile(current, latest) && latest.Size() >= offset {
+			continue
+		}
+
+		nf, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		if !os.SameFile(current, latest) {
+			// read what was written to the old file before it was replaced
+			if err := p.read(io.MultiReader(bytes.NewReader(partial), br)); err != nil {
+				nf.Close()
+				return err
+			}
+		}
+		f.Close()
+		f, br, offset, partial = nf, bufio.NewReader(nf), 0, nil
+	}
+}
+
+// print writes line if it passes the filter. Lines that are not JSON
+// objects are always written.
+func (p *printer) print(line []byte) error {
+	line = bytes.TrimRight(line, "\r\n")
+	e, ok := parseEntry(line)
+	if !ok {
+		if _, err := p.out.Write(line); err != nil {
+			return err
+		}
+		return p.out.WriteByte('\n')
+	}
+	if !p.filter.match(e) {
+		return nil
+	}
+
+	return p.write(e)
+}
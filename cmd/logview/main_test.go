@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+const testLines = `{"time":"2024-03-01T10:00:00Z","level":"debug","message":"starting","logger":"api"}
+not json
+{"time":"2024-03-01T11:00:00Z","level":"info","message":"request handled","logger":"api","http":{"status":200},"user_id":3}
+{"time":"2024-03-01T12:00:00Z","level":"error","message":"request failed","logger":"db","error":{"message":"timeout"}}
+`
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		exp  []string
+	}{
+		{
+			name: "all",
+			exp:  []string{"starting", "not json", "request handled", "request failed"},
+		},
+		{
+			name: "level",
+			args: []string{"-level", "info"},
+			exp:  []string{"not json", "request handled", "request failed"},
+		},
+		{
+			name: "time range",
+			args: []string{"-since", "2024-03-01T10:30:00Z", "-until", "2024-03-01T12:00:00Z"},
+			exp:  []string{"not json", "request handled"},
+		},
+		{
+			name: "message",
+			args: []string{"-message", "^request"},
+			exp:  []string{"not json", "request handled", "request failed"},
+		},
+		{
+			name: "fields",
+			args: []string{"-field", "logger=api", "-field", "http.status=200"},
+			exp:  []string{"not json", "request handled"},
+		},
+		{
+			name: "error",
+			args: []string{"-field", "error=timeout"},
+			exp:  []string{"not json", "request failed"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			test.OK(t, run(context.Background(), tc.args, strings.NewReader(testLines), out))
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			test.Equals(t, len(tc.exp), len(lines))
+			for i, exp := range tc.exp {
+				test.Includes(t, exp, lines[i])
+			}
+		})
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	out := &bytes.Buffer{}
+	line := `{"time":"2024-03-01T11:00:00Z","level":"info","message":"done","b":"two words","a":{"x":1}}` + "\n"
+	test.OK(t, run(context.Background(), nil, strings.NewReader(line), out))
+	test.Includes(t, `INFO  done`, out.String())
+	test.Includes(t, `b="two words" a={"x":1}`, out.String())
+	test.NotIncludes(t, "\x1b[", out.String())
+
+	out.Reset()
+	test.OK(t, run(context.Background(), []string{"-color"}, strings.NewReader(line), out))
+	test.Includes(t, "\x1b[34mINFO \x1b[0m", out.String())
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "app.log")
+	test.OK(t, os.WriteFile(plain, []byte(testLines), 0o644))
+
+	compressed := &bytes.Buffer{}
+	zw := gzip.NewWriter(compressed)
+	_, err := zw.Write([]byte(`{"time":"2024-02-29T10:00:00Z","level":"error","message":"rotated"}` + "\n"))
+	test.OK(t, err)
+	test.OK(t, zw.Close())
+	rotated := filepath.Join(dir, "app.log.1.gz")
+	test.OK(t, os.WriteFile(rotated, compressed.Bytes(), 0o644))
+
+	out := &bytes.Buffer{}
+	test.OK(t, run(context.Background(), []string{"-level", "error", rotated, plain}, nil, out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	test.Equals(t, 3, len(lines))
+	test.Includes(t, "rotated", lines[0])
+	test.Includes(t, "not json", lines[1])
+	test.Includes(t, "request failed", lines[2])
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	return sb.buf.String()
+}
+
+func appendFile(t *testing.T, name, s string) {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	test.OK(t, err)
+	_, err = f.WriteString(s)
+	test.OK(t, err)
+	test.OK(t, f.Close())
+}
+
+func waitFor(t *testing.T, out *syncBuffer, exp string) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), exp) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q not printed, got:\n%s", exp, out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunFollow(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, name, `{"level":"info","message":"one"}`+"\n"+`{"level":"info","mess`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- run(ctx, []string{"-follow", name}, nil, out)
+	}()
+	waitFor(t, out, "one")
+
+	// the partial line is completed
+	appendFile(t, name, `age":"two"}`+"\n")
+	waitFor(t, out, "two")
+
+	// truncated, the file is read from the start
+	test.OK(t, os.Truncate(name, 0))
+	appendFile(t, name, `{"level":"info","message":"three"}`+"\n")
+	waitFor(t, out, "three")
+
+	// rotated, the rest of the old file is read before the new one
+	appendFile(t, name, `{"level":"info","message":"four"}`+"\n")
+	test.OK(t, os.Rename(name, name+".1"))
+	appendFile(t, name, `{"level":"info","message":"five"}`+"\n"+`not terminated`)
+	waitFor(t, out, "five")
+
+	cancel()
+	select {
+	case err := <-done:
+		test.OK(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("follow did not stop")
+	}
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		messages = append(messages, strings.TrimSpace(strings.TrimPrefix(line, "INFO ")))
+	}
+	test.Equals(t, []string{"one", "two", "three", "four", "five", "not terminated"}, messages)
+}
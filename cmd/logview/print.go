@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorBlue  = "\x1b[34m"
+	colorGray  = "\x1b[90m"
+	colorBold  = "\x1b[1m"
+
+	timeFormat   = "2006-01-02 15:04:05.000"
+	levelWidth   = 5
+	messageWidth = 40
+)
+
+type field struct {
+	key   string
+	value json.RawMessage
+}
+
+// entry is a parsed line. The fields keep the order of the line, values
+// holds them decoded for filtering.
+type entry struct {
+	time    time.Time
+	level   log.LogLevel
+	message string
+	fields  []field
+	values  map[string]interface{}
+}
+
+// parseEntry parses a line written in FormatJSON. It reports false for
+// lines that are not a JSON object.
+func parseEntry(line []byte) (entry, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return entry{}, false
+	}
+
+	var e entry
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&e.values); err != nil {
+		return entry{}, false
+	}
+
+	// decode again token by token to get the order of the keys
+	dec = json.NewDecoder(bytes.NewReader(line))
+	if _, err := dec.Token(); err != nil {
+		return entry{}, false
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return entry{}, false
+		}
+		key, _ := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return entry{}, false
+		}
+		switch key {
+		case "time":
+			e.time = parseEntryTime(e.values[key])
+		case "level":
+			s, _ := e.values[key].(string)
+			e.level = log.LogLevel(s)
+		case "message":
+			e.message, _ = e.values[key].(string)
+		default:
+			e.fields = setField(e.fields, key, raw)
+		}
+	}
+
+	return e, true
+}
+
+// parseEntryTime accepts both time formats of FormatJSON.
+func parseEntryTime(value interface{}) time.Time {
+	switch v := value.(type) {
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	case json.Number:
+		ms, err := v.Int64()
+		if err != nil {
+			return time.Time{}
+		}
+		return time.UnixMilli(ms)
+	default:
+		return time.Time{}
+	}
+}
+
+// setField adds a field, or replaces the value of an earlier one with the
+// same key, as decoding into a map does.
+func setField(fields []field, key string, value json.RawMessage) []field {
+	for i := range fields {
+		if fields[i].key == key {
+			fields[i].value = value
+			return fields
+		}
+	}
+
+	return append(fields, field{key: key, value: value})
+}
+
+// filter selects entries. The zero value lets everything through.
+type filter struct {
+	level   log.LogLevel
+	since   time.Time
+	until   time.Time
+	message *regexp.Regexp
+	fields  fieldFilters
+}
+
+func (f filter) match(e entry) bool {
+	if f.level != "" && levelRank(e.level) < levelRank(f.level) {
+		return false
+	}
+	if !f.since.IsZero() || !f.until.IsZero() {
+		if e.time.IsZero() {
+			return false
+		}
+		if !f.since.IsZero() && e.time.Before(f.since) {
+			return false
+		}
+		if !f.until.IsZero() && !e.time.Before(f.until) {
+			return false
+		}
+	}
+	if f.message != nil && !f.message.MatchString(e.message) {
+		return false
+	}
+	for k, want := range f.fields {
+		value, ok := lookup(e.values, k)
+		if !ok || text(value) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+func levelRank(level log.LogLevel) int {
+	switch level {
+	case log.LevelDebug:
+		return 0
+	case log.LevelError:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// lookup finds key in m. A dotted key is also looked up in nested objects,
+// so "http.status" finds both {"http.status":200} and {"http":{"status":200}}.
+func lookup(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '.' {
+			continue
+		}
+		if sub, ok := m[key[:i]].(map[string]interface{}); ok {
+			if v, ok := lookup(sub, key[i+1:]); ok {
+				return v, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// text returns value as it is compared with a field filter. Expanded
+// errors are compared on their message.
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case map[string]interface{}:
+		if msg, ok := v["message"].(string); ok {
+			return msg
+		}
+		b, _ := json.Marshal(v)
+		return string(b)
+	case []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type printer struct {
+	out    *bufio.Writer
+	filter filter
+	color  bool
+}
+
+// write prints e like the console format of GoKitIOLogger, with the fields
+// in the order of the line.
+func (p *printer) write(e entry) error {
+	buf := make([]byte, 0, 256)
+	if !e.time.IsZero() {
+		buf = p.appendColored(buf, colorGray, e.time.Local().Format(timeFormat))
+		buf = append(buf, ' ')
+	}
+	level := strings.ToUpper(string(e.level))
+	for i := len(level); i < levelWidth; i++ {
+		level += " "
+	}
+	buf = p.appendColored(buf, levelColor(e.level), level)
+	buf = append(buf, ' ')
+	buf = append(buf, e.message...)
+	for i, f := range e.fields {
+		if i == 0 {
+			for j := len(e.message); j < messageWidth; j++ {
+				buf = append(buf, ' ')
+			}
+		}
+		buf = append(buf, ' ')
+		value := fieldValue(f.value)
+		if f.key == "error" || strings.HasPrefix(f.key, "error.") {
+			buf = p.appendColored(buf, colorRed+colorBold, f.key+"="+value)
+			continue
+		}
+		buf = p.appendColored(buf, colorGray, f.key+"=")
+		buf = append(buf, value...)
+	}
+	buf = append(buf, '\n')
+
+	_, err := p.out.Write(buf)
+	return err
+}
+
+func (p *printer) appendColored(buf []byte, color, s string) []byte {
+	if !p.color {
+		return append(buf, s...)
+	}
+	buf = append(buf, color...)
+	buf = append(buf, s...)
+
+	return append(buf, colorReset...)
+}
+
+func levelColor(level log.LogLevel) string {
+	switch level {
+	case log.LevelDebug:
+		return colorGray
+	case log.LevelError:
+		return colorRed
+	default:
+		return colorBlue
+	}
+}
+
+// fieldValue renders a raw JSON value. Strings are unquoted unless they
+// need quotes to be read back, objects and arrays are compacted.
+func fieldValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	b := &bytes.Buffer{}
+	if err := json.Compact(b, raw); err != nil {
+		return string(raw)
+	}
+
+	return b.String()
+}
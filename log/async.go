@@ -0,0 +1,195 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	PolicyBlock = FullPolicy("block")
+	PolicyDrop  = FullPolicy("drop")
+)
+
+// FullPolicy determines what an AsyncWriter does with a line when its queue
+// is full.
+type FullPolicy string
+
+var ErrWriterClosed = errors.New("writer is closed")
+
+// AsyncConfig configures an AsyncWriter. QueueSize is the number of lines
+// that can be waiting, it defaults to 1024. BatchSize is the maximum number
+// of lines that are combined in a single write, it defaults to 64. Policy
+// defaults to PolicyBlock.
+type AsyncConfig struct {
+	QueueSize int
+	BatchSize int
+	Policy    FullPolicy
+}
+
+// AsyncWriter is an io.Writer that queues lines and writes them to the
+// underlying writer in the background. Close stops the writer after all
+// queued lines are written. It does not close the underlying writer.
+type AsyncWriter struct {
+	out     io.Writer
+	config  AsyncConfig
+	lines   chan []byte
+	flush   chan chan struct{}
+	stopped chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+func NewAsyncWriter(out io.Writer, config AsyncConfig) *AsyncWriter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 64
+	}
+	if config.Policy == "" {
+		config.Policy = PolicyBlock
+	}
+
+	aw := &AsyncWriter{
+		out:     out,
+		config:  config,
+		lines:   make(chan []byte, config.QueueSize),
+		flush:   make(chan chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go aw.run()
+
+	return aw
+}
+
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	if aw.closed {
+		return 0, ErrWriterClosed
+	}
+
+	if aw.config.Policy == PolicyDrop {
+		select {
+		case aw.lines <- line:
+		default:
+			aw.dropped.Add(1)
+		}
+		return len(p), nil
+	}
+	aw.lines <- line
+
+	return len(p), nil
+}
+
+// Flush blocks until all lines that were queued before the call are
+// written.
+func (aw *AsyncWriter) Flush() error {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	if aw.closed {
+		return ErrWriterClosed
+	}
+
+	done := make(chan struct{})
+	aw.flush <- done
+	<-done
+
+	return nil
+}
+
+func (aw *AsyncWriter) Close() error {
+	aw.mu.Lock()
+	if aw.closed {
+		aw.mu.Unlock()
+		return ErrWriterClosed
+	}
+	aw.closed = true
+	close(aw.lines)
+	aw.mu.Unlock()
+
+	<-aw.stopped
+
+	return nil
+}
+
+// Dropped returns the number of lines that were discarded because the queue
+// was full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
+// Failed returns the number of lines that could not be written to the
+// underlying writer.
+func (aw *AsyncWriter) Failed() uint64 {
+	return aw.failed.Load()
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.stopped)
+
+	batch := &bytes.Buffer{}
+	for {
+		select {
+		case line, ok := <-aw.lines:
+			if !ok {
+				return
+			}
+			count := 1
+			batch.Write(line)
+		fill:
+			for count < aw.config.BatchSize {
+				select {
+				case line, ok := <-aw.lines:
+					if !ok {
+						break fill
+					}
+					count++
+					batch.Write(line)
+				default:
+					break fill
+				}
+			}
+			aw.write(batch, count)
+		case done := <-aw.flush:
+			count := 0
+		drain:
+			for {
+				select {
+				case line, ok := <-aw.lines:
+					if !ok {
+						break drain
+					}
+					count++
+					batch.Write(line)
+					if count == aw.config.BatchSize {
+						aw.write(batch, count)
+						count = 0
+					}
+				default:
+					break drain
+				}
+			}
+			aw.write(batch, count)
+			close(done)
+		}
+	}
+}
+
+func (aw *AsyncWriter) write(batch *bytes.Buffer, count int) {
+	if count == 0 {
+		return
+	}
+	if _, err := aw.out.Write(batch.Bytes()); err != nil {
+		aw.failed.Add(uint64(count))
+	}
+	batch.Reset()
+}
@@ -0,0 +1,98 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+type syncWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+	block  chan struct{}
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	if sw.block != nil {
+		<-sw.block
+	}
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.writes++
+
+	return sw.buf.Write(p)
+}
+
+func (sw *syncWriter) String() string {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	return sw.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	sw := &syncWriter{}
+	aw := log.NewAsyncWriter(sw, log.AsyncConfig{})
+	logger := log.New(aw)
+
+	for i := 0; i < 100; i++ {
+		logger.WithField("i", i).Info("message")
+	}
+	test.OK(t, aw.Flush())
+
+	lines := strings.Split(strings.TrimSpace(sw.String()), "\n")
+	test.Equals(t, 100, len(lines))
+	for i, line := range lines {
+		test.Includes(t, fmt.Sprintf(`"i":%d`, i), line)
+	}
+	test.Equals(t, uint64(0), aw.Dropped())
+
+	logger.Info("last")
+	test.OK(t, aw.Close())
+	test.Includes(t, "last", sw.String())
+
+	_, err := aw.Write([]byte("closed"))
+	test.Equals(t, log.ErrWriterClosed, err)
+}
+
+func TestAsyncWriterBatch(t *testing.T) {
+	sw := &syncWriter{block: make(chan struct{})}
+	aw := log.NewAsyncWriter(sw, log.AsyncConfig{BatchSize: 5})
+
+	aw.Write([]byte("first\n"))
+	for i := 0; i < 10; i++ {
+		aw.Write([]byte("line\n"))
+	}
+	close(sw.block)
+	test.OK(t, aw.Close())
+
+	test.Equals(t, 11, strings.Count(sw.String(), "\n"))
+	test.Assert(t, sw.writes < 11, "expected lines to be batched", sw.writes)
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	sw := &syncWriter{block: make(chan struct{})}
+	aw := log.NewAsyncWriter(sw, log.AsyncConfig{
+		QueueSize: 2,
+		BatchSize: 1,
+		Policy:    log.PolicyDrop,
+	})
+
+	for i := 0; i < 10; i++ {
+		n, err := aw.Write([]byte("line\n"))
+		test.OK(t, err)
+		test.Equals(t, 5, n)
+	}
+	close(sw.block)
+	test.OK(t, aw.Close())
+
+	written := uint64(strings.Count(sw.String(), "\n"))
+	test.Assert(t, aw.Dropped() >= 7, "expected lines to be dropped", aw.Dropped())
+	test.Equals(t, uint64(10), written+aw.Dropped())
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// AtomicLevel is a LevelSpec that can be read and changed concurrently. All
+// loggers derived from a logger share its AtomicLevel, so a change is
+// observed by every one of them. The zero value logs at level info.
+type AtomicLevel struct {
+	spec atomic.Pointer[LevelSpec]
+}
+
+func NewAtomicLevel(level LogLevel) *AtomicLevel {
+	al := &AtomicLevel{}
+	al.SetLevel(level)
+
+	return al
+}
+
+// Level returns the level for the logger with the given name.
+func (al *AtomicLevel) Level(name string) LogLevel {
+	return al.Spec().Level(name)
+}
+
+func (al *AtomicLevel) Spec() LevelSpec {
+	if spec := al.spec.Load(); spec != nil {
+		return *spec
+	}
+
+	return LevelSpec{Default: LevelInfo}
+}
+
+// SetLevel changes the default level and leaves the named levels as they
+// are.
+func (al *AtomicLevel) SetLevel(level LogLevel) {
+	for {
+		current := al.spec.Load()
+		spec := &LevelSpec{Default: level}
+		if current != nil {
+			spec.Names = current.Names
+		}
+		if al.spec.CompareAndSwap(current, spec) {
+			return
+		}
+	}
+}
+
+// SetNamedLevel changes the level of the logger with the given name and its
+// children, or the default level if name is empty.
+func (al *AtomicLevel) SetNamedLevel(name string, level LogLevel) {
+	if name == "" {
+		al.SetLevel(level)
+		return
+	}
+	for {
+		current := al.spec.Load()
+		spec := &LevelSpec{Default: LevelInfo}
+		if current != nil {
+			spec.Default = current.Default
+		}
+		spec.Names = make(map[string]LogLevel)
+		if current != nil {
+			for k, v := range current.Names {
+				spec.Names[k] = v
+			}
+		}
+		spec.Names[name] = level
+		if al.spec.CompareAndSwap(current, spec) {
+			return
+		}
+	}
+}
+
+func (al *AtomicLevel) SetSpec(spec LevelSpec) {
+	names := make(map[string]LogLevel, len(spec.Names))
+	for k, v := range spec.Names {
+		names[k] = v
+	}
+	spec.Names = names
+
+	al.spec.Store(&spec)
+}
+
+type levelPayload struct {
+	Level LogLevel `json:"level,omitempty"`
+	Spec  string   `json:"spec,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// ServeHTTP reports the current levels on GET. On PUT it accepts a JSON body
+// with either a "level" to change the default level, or a "spec" in the
+// format of ParseLevelSpec to replace all levels.
+func (al *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+			return
+		}
+		switch {
+		case req.Spec != "":
+			spec, err := ParseLevelSpec(req.Spec)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+				return
+			}
+			al.SetSpec(spec)
+		case req.Level != "":
+			level, err := ParseLogLevel(string(req.Level))
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, levelPayload{Error: err.Error()})
+				return
+			}
+			al.SetLevel(level)
+		default:
+			writeJSON(w, http.StatusBadRequest, levelPayload{Error: "level or spec is required"})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeJSON(w, http.StatusMethodNotAllowed, levelPayload{Error: "method not allowed"})
+		return
+	}
+
+	spec := al.Spec()
+	writeJSON(w, http.StatusOK, levelPayload{
+		Level: spec.Level(""),
+		Spec:  spec.String(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
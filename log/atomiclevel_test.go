@@ -0,0 +1,156 @@
+package log_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestAtomicLevelShared(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw)
+	derived := logger.WithField("key", "value")
+	named := logger.Named("db")
+
+	derived.Debug("debug")
+	named.Debug("debug")
+	test.Equals(t, 0, len(tw.LogLines))
+
+	logger.SetLogLevel(log.LevelDebug)
+	derived.Debug("debug")
+	named.Debug("debug")
+	test.Equals(t, 2, len(tw.LogLines))
+
+	tw.Flush()
+	spec, err := log.ParseLevelSpec("error,db=debug")
+	test.OK(t, err)
+	derived.(*log.GoKitIOLogger).SetLevelSpec(spec)
+	logger.Info("info")
+	named.Debug("debug")
+	test.Equals(t, 1, len(tw.LogLines))
+	test.Includes(t, `"logger":"db"`, tw.LogLines[0])
+}
+
+func TestAtomicLevelServeHTTP(t *testing.T) {
+	level := log.NewAtomicLevel(log.LevelInfo)
+
+	for _, tc := range []struct {
+		name      string
+		method    string
+		body      string
+		expStatus int
+		expLevel  string
+		expSpec   string
+	}{
+		{
+			name:      "get",
+			method:    http.MethodGet,
+			expStatus: http.StatusOK,
+			expLevel:  "info",
+			expSpec:   "info",
+		},
+		{
+			name:      "put level",
+			method:    http.MethodPut,
+			body:      `{"level":"debug"}`,
+			expStatus: http.StatusOK,
+			expLevel:  "debug",
+			expSpec:   "debug",
+		},
+		{
+			name:      "put spec",
+			method:    http.MethodPut,
+			body:      `{"spec":"error,db=debug"}`,
+			expStatus: http.StatusOK,
+			expLevel:  "error",
+			expSpec:   "error,db=debug",
+		},
+		{
+			name:      "put level keeps names",
+			method:    http.MethodPut,
+			body:      `{"level":"info"}`,
+			expStatus: http.StatusOK,
+			expLevel:  "info",
+			expSpec:   "info,db=debug",
+		},
+		{
+			name:      "invalid level",
+			method:    http.MethodPut,
+			body:      `{"level":"verbose"}`,
+			expStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "empty body",
+			method:    http.MethodPut,
+			body:      `{}`,
+			expStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "wrong method",
+			method:    http.MethodPost,
+			expStatus: http.StatusMethodNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			level.ServeHTTP(rec, httptest.NewRequest(tc.method, "/level", strings.NewReader(tc.body)))
+
+			test.Equals(t, tc.expStatus, rec.Code)
+			if tc.expStatus != http.StatusOK {
+				return
+			}
+			var act struct {
+				Level string `json:"level"`
+				Spec  string `json:"spec"`
+			}
+			test.OK(t, json.Unmarshal(rec.Body.Bytes(), &act))
+			test.Equals(t, tc.expLevel, act.Level)
+			test.Equals(t, tc.expSpec, act.Spec)
+		})
+	}
+}
+
+func TestSetLogLevelNamed(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		logger log.Logger
+	}{
+		{
+			name:   "gokitio",
+			logger: log.NewGoKitIOLogger(&testWriter{}),
+		},
+		{
+			name:   "slog",
+			logger: log.NewSlogLogger(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		},
+		{
+			name:   "ring",
+			logger: log.NewRingBuffer(1),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := tc.logger
+			root.SetLogLevel(log.LevelInfo)
+			db := root.Named("db")
+			pool := db.Named("pool")
+			http := root.Named("http")
+
+			db.SetLogLevel(log.LevelError)
+			test.Equals(t, true, root.Enabled(log.LevelInfo))
+			test.Equals(t, true, http.Enabled(log.LevelInfo))
+			test.Equals(t, false, db.Enabled(log.LevelInfo))
+			test.Equals(t, false, pool.Enabled(log.LevelInfo))
+
+			root.SetLogLevel(log.LevelDebug)
+			test.Equals(t, true, http.Enabled(log.LevelDebug))
+			test.Equals(t, false, db.Enabled(log.LevelInfo))
+		})
+	}
+}
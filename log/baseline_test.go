@@ -0,0 +1,295 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// NewBaselineLogger lets the benchmarks compare GoKitIOLogger with its
+// previous implementation.
+var NewBaselineLogger = newBaselineLogger
+
+// baselineLogger is GoKitIOLogger as it was before the fields were
+// pre-encoded, with the identifiers renamed and only the JSON and logfmt
+// formats. It copies the fields on every derived logger and builds a key
+// value slice for go-kit on every line.
+type baselineLogger struct {
+	fields []baselineField
+	name   string
+	group  string
+	opts   *options
+	logger kitlog.Logger
+}
+
+type baselineField struct {
+	key   string
+	value interface{}
+}
+
+func newBaselineLogger(out io.Writer, opts ...Option) Logger {
+	o := newOptions(opts)
+
+	var kl kitlog.Logger
+	switch o.format {
+	case FormatLogfmt:
+		kl = kitlog.NewLogfmtLogger(out)
+	default:
+		kl = newBaselineJSONLogger(out)
+	}
+
+	return &baselineLogger{
+		opts:   o,
+		logger: kl,
+	}
+}
+
+func (kl *baselineLogger) SetLogLevel(loglevel LogLevel) {
+	kl.opts.level.SetLevel(loglevel)
+}
+
+func (kl *baselineLogger) SetLevelSpec(spec LevelSpec) {
+	kl.opts.level.SetSpec(spec)
+}
+
+func (kl *baselineLogger) Named(name string) Logger {
+	fullName := joinName(kl.name, name)
+	newFields := make([]baselineField, len(kl.fields), len(kl.fields)+1)
+	copy(newFields, kl.fields)
+
+	return &baselineLogger{
+		fields: setBaselineField(newFields, nameKey, fullName),
+		name:   fullName,
+		group:  kl.group,
+		opts:   kl.opts,
+		logger: kl.logger,
+	}
+}
+
+func (kl *baselineLogger) WithGroup(name string) Logger {
+	if name == "" {
+		return kl
+	}
+
+	return &baselineLogger{
+		fields: kl.fields,
+		name:   kl.name,
+		group:  kl.group + name + ".",
+		opts:   kl.opts,
+		logger: kl.logger,
+	}
+}
+
+func (kl *baselineLogger) WithField(key string, value interface{}) Logger {
+	return kl.With(Fields{
+		key: value,
+	})
+}
+
+func (kl *baselineLogger) WithErr(err error) Logger {
+	return kl.With(Fields{
+		"error": errorValue{err: err},
+	})
+}
+
+func (kl *baselineLogger) With(fields Fields) Logger {
+	newFields := make([]baselineField, len(kl.fields), len(kl.fields)+len(fields))
+	copy(newFields, kl.fields)
+	for _, k := range sortedKeys(fields) {
+		key := kl.group + k
+		if kl.opts.reserved(key) {
+			key = reservedPrefix + key
+		}
+		newFields = setBaselineField(newFields, key, fields[k])
+	}
+
+	return &baselineLogger{
+		fields: newFields,
+		name:   kl.name,
+		group:  kl.group,
+		opts:   kl.opts,
+		logger: kl.logger,
+	}
+}
+
+func (kl *baselineLogger) Debug(message string) {
+	kl.log(LevelDebug, message)
+}
+
+func (kl *baselineLogger) Info(message string) {
+	kl.log(LevelInfo, message)
+}
+
+func (kl *baselineLogger) Error(message string) {
+	kl.log(LevelError, message)
+}
+
+func (kl *baselineLogger) Enabled(level LogLevel) bool {
+	return kl.opts.level.Level(kl.name).allows(level)
+}
+
+func (kl *baselineLogger) log(level LogLevel, message string) {
+	if !kl.Enabled(level) {
+		return
+	}
+
+	kv := make([]interface{}, 0, 10+2*len(kl.fields))
+	kv = append(kv,
+		kl.opts.keys.Time, baselineTimestamp(kl.opts, time.Now()),
+		kl.opts.keys.Level, string(level),
+		kl.opts.keys.Message, message,
+	)
+	if kl.opts.caller {
+		if frame, ok := caller(); ok {
+			kv = append(kv, callerKey, shortFile(frame.File, frame.Line))
+			if kl.opts.function {
+				kv = append(kv, functionKey, shortFunction(frame.Function))
+			}
+		}
+	}
+	for _, f := range kl.fields {
+		kv = kl.appendField(kv, f.key, f.value)
+	}
+
+	kl.logger.Log(kv...)
+}
+
+func (kl *baselineLogger) appendField(kv []interface{}, key string, value interface{}) []interface{} {
+	if lv, ok := value.(LazyValue); ok {
+		value = lv()
+	}
+	ev, isErr := value.(errorValue)
+	if isErr {
+		if ev.err == nil {
+			return append(kv, key, nil)
+		}
+		value = expandError(ev.err)
+	}
+	if kl.opts.format == FormatJSON || kl.opts.format == "" {
+		return append(kv, key, value)
+	}
+	if !isErr {
+		return baselineFlatten(kv, key, value)
+	}
+
+	m := value.(map[string]interface{})
+	kv = append(kv, key, m["message"])
+	for _, k := range sortedKeys(m) {
+		if k != "message" {
+			kv = baselineFlatten(kv, key+"."+k, m[k])
+		}
+	}
+
+	return kv
+}
+
+func setBaselineField(fields []baselineField, key string, value interface{}) []baselineField {
+	for i := range fields {
+		if fields[i].key == key {
+			fields[i].value = value
+			return fields
+		}
+	}
+
+	return append(fields, baselineField{key: key, value: value})
+}
+
+func baselineTimestamp(o *options, t time.Time) interface{} {
+	if o.format == FormatConsole || o.format == FormatSyslog {
+		return t
+	}
+	if o.timeFormat == TimeFormatUnixMilli {
+		return t.UnixMilli()
+	}
+
+	return t.UTC().Format(o.timeFormat)
+}
+
+func baselineFlatten(kv []interface{}, key string, value interface{}) []interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			kv = baselineFlatten(kv, key+"."+k, v[k])
+		}
+	case Fields:
+		for _, k := range sortedKeys(v) {
+			kv = baselineFlatten(kv, key+"."+k, v[k])
+		}
+	case []interface{}:
+		for i, item := range v {
+			kv = baselineFlatten(kv, key+"."+strconv.Itoa(i), item)
+		}
+	default:
+		kv = append(kv, key, value)
+	}
+
+	return kv
+}
+
+type baselineJSONLogger struct {
+	out io.Writer
+}
+
+func newBaselineJSONLogger(out io.Writer) *baselineJSONLogger {
+	return &baselineJSONLogger{
+		out: out,
+	}
+}
+
+func (jl *baselineJSONLogger) Log(keyvals ...interface{}) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		enc.Encode(fmt.Sprint(keyvals[i]))
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(baselineJSONValue(value)); err != nil {
+			enc.Encode(fmt.Sprintf("%+v", value))
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}\n")
+
+	_, err := jl.out.Write(buf.Bytes())
+	return err
+}
+
+func baselineJSONValue(value interface{}) (v interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				v = nil
+				return
+			}
+			v = fmt.Sprintf("PANIC in value: %v", r)
+		}
+	}()
+
+	switch x := value.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return x
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	default:
+		return x
+	}
+}
@@ -0,0 +1,144 @@
+package log_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+)
+
+var benchLoggers = []struct {
+	name   string
+	format log.Format
+	new    func(io.Writer, log.Format) log.Logger
+}{
+	{
+		name:   "baseline/json",
+		format: log.FormatJSON,
+		new:    newBaselineLogger,
+	},
+	{
+		name:   "encoded/json",
+		format: log.FormatJSON,
+		new:    newEncodedLogger,
+	},
+	{
+		name:   "baseline/logfmt",
+		format: log.FormatLogfmt,
+		new:    newBaselineLogger,
+	},
+	{
+		name:   "encoded/logfmt",
+		format: log.FormatLogfmt,
+		new:    newEncodedLogger,
+	},
+}
+
+// newBaselineLogger returns the implementation of GoKitIOLogger from before
+// the fields were pre-encoded, see baseline_test.go.
+func newBaselineLogger(out io.Writer, format log.Format) log.Logger {
+	return log.NewBaselineLogger(out, log.WithFormat(format))
+}
+
+func newEncodedLogger(out io.Writer, format log.Format) log.Logger {
+	return log.NewGoKitIOLogger(out, log.WithFormat(format))
+}
+
+func benchContext(logger log.Logger) log.Logger {
+	return logger.With(log.Fields{
+		"service":  "api",
+		"version":  "1.2.3",
+		"host":     "web-01",
+		"region":   "eu-west-1",
+		"user_id":  12345,
+		"session":  "7f3c9a2e",
+		"method":   "GET",
+		"path":     "/users/12345",
+		"attempt":  2,
+		"duration": 150 * time.Millisecond,
+	})
+}
+
+func BenchmarkWith(b *testing.B) {
+	for _, bl := range benchLoggers {
+		b.Run(bl.name, func(b *testing.B) {
+			logger := benchContext(bl.new(io.Discard, bl.format))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.With(log.Fields{"request_id": "abc"})
+			}
+		})
+	}
+}
+
+func BenchmarkWithField(b *testing.B) {
+	for _, bl := range benchLoggers {
+		b.Run(bl.name, func(b *testing.B) {
+			logger := benchContext(bl.new(io.Discard, bl.format))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.WithField("request_id", "abc")
+			}
+		})
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	for _, bl := range benchLoggers {
+		b.Run(bl.name, func(b *testing.B) {
+			logger := benchContext(bl.new(io.Discard, bl.format))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.Info("request handled")
+			}
+		})
+	}
+}
+
+func BenchmarkInfoParallel(b *testing.B) {
+	for _, bl := range benchLoggers {
+		b.Run(bl.name, func(b *testing.B) {
+			logger := benchContext(bl.new(io.Discard, bl.format))
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.Info("request handled")
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkDebugDisabled(b *testing.B) {
+	for _, bl := range benchLoggers {
+		b.Run(bl.name, func(b *testing.B) {
+			logger := benchContext(bl.new(io.Discard, bl.format))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.Debug("request handled")
+			}
+		})
+	}
+}
+
+// BenchmarkWithDebugDisabled derives a logger for a single line that is
+// discarded, as is common for debug lines in request handlers.
+func BenchmarkWithDebugDisabled(b *testing.B) {
+	for _, bl := range benchLoggers {
+		b.Run(bl.name, func(b *testing.B) {
+			logger := benchContext(bl.new(io.Discard, bl.format))
+			req := map[string]interface{}{"id": "abc", "path": "/users/12345"}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.With(log.Fields{"req": req, "n": i}).Debug("request handled")
+			}
+		})
+	}
+}
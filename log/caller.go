@@ -0,0 +1,84 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	callerKey   = "caller"
+	functionKey = "function"
+)
+
+var (
+	helpers sync.Map
+
+	// internalPrefixes are the function name prefixes of the packages that
+	// are never reported as caller.
+	internalPrefixes = []string{
+		reflect.TypeOf(GoKitIOLogger{}).PkgPath() + ".",
+		"log/slog.",
+		"log.",
+	}
+)
+
+// Helper marks the calling function as a log helper. Like testing.T.Helper,
+// it makes the logger report the caller of the helper as the source of the
+// line, instead of the helper itself.
+func Helper() {
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		helpers.Store(fn.Name(), struct{}{})
+	}
+}
+
+// caller returns the first frame on the stack that is not part of this
+// package or a helper.
+func caller() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isInternal(frame.Function) {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+func isInternal(function string) bool {
+	for _, prefix := range internalPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	_, helper := helpers.Load(function)
+
+	return helper
+}
+
+// shortFile returns the file name with its directory, like "log/caller.go".
+func shortFile(file string, line int) string {
+	dir, name := filepath.Split(file)
+
+	return fmt.Sprintf("%s:%d", filepath.Join(filepath.Base(dir), name), line)
+}
+
+// shortFunction strips the path from the package of a function name.
+func shortFunction(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		return function[i+1:]
+	}
+
+	return function
+}
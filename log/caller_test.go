@@ -0,0 +1,94 @@
+package log_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func logFailure(logger log.Logger) {
+	log.Helper()
+	logger.Error("failure")
+}
+
+func TestCaller(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw, log.WithCaller(true))
+	sampled := log.NewSampler(logger, log.SamplerConfig{})
+	slogger := slog.New(log.NewSlogHandler(logger))
+
+	for _, tc := range []struct {
+		name string
+		log  func() int
+	}{
+		{
+			name: "direct",
+			log: func() int {
+				logger.Info("message")
+				return line()
+			},
+		},
+		{
+			name: "with field",
+			log: func() int {
+				logger.WithField("key", "value").Info("message")
+				return line()
+			},
+		},
+		{
+			name: "wrapper",
+			log: func() int {
+				sampled.WithField("key", "value").Info("message")
+				return line()
+			},
+		},
+		{
+			name: "slog",
+			log: func() int {
+				slogger.Info("message")
+				return line()
+			},
+		},
+		{
+			name: "helper",
+			log: func() int {
+				logFailure(logger)
+				return line()
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw.Flush()
+			expLine := tc.log()
+
+			test.Equals(t, 1, len(tw.LogLines))
+			var act struct {
+				Caller   string `json:"caller"`
+				Function string `json:"function"`
+			}
+			test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &act))
+			test.Equals(t, fmt.Sprintf("log/caller_test.go:%d", expLine), act.Caller)
+			test.Equals(t, "log_test.TestCaller.func", act.Function[:len("log_test.TestCaller.func")])
+		})
+	}
+}
+
+func TestCallerDisabled(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw)
+	logger.WithField("caller", "field").Info("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	test.Includes(t, `"caller":"field"`, tw.LogLines[0])
+}
+
+// line returns the line before the one it is called from.
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l - 1
+}
@@ -0,0 +1,192 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorBlue  = "\x1b[34m"
+	colorGray  = "\x1b[90m"
+	colorBold  = "\x1b[1m"
+
+	consoleTimeFormat   = "2006-01-02 15:04:05.000"
+	consoleLevelWidth   = 5
+	consoleMessageWidth = 40
+	consoleMultiIndent  = "    "
+)
+
+// consoleEncoder writes human friendly lines for use during development.
+// The fields are sorted on every line, so they are encoded as records of
+// the rendered key and value first.
+type consoleEncoder struct {
+	color bool
+}
+
+type consoleField struct {
+	key   []byte
+	value []byte
+	isErr bool
+}
+
+func newConsoleEncoder(color bool) *consoleEncoder {
+	return &consoleEncoder{
+		color: color,
+	}
+}
+
+// useColor reports whether out is a terminal and colors are not disabled
+// through the NO_COLOR environment variable.
+func useColor(out io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// appendField appends a record with a flag for error values, followed by
+// the length and bytes of the key and of the rendered value.
+func (ce *consoleEncoder) appendField(buf []byte, key string, value interface{}) []byte {
+	flag := byte(0)
+	if _, isErr := value.(error); isErr {
+		flag = 1
+	}
+	rendered := consoleValue(value)
+	buf = append(buf, flag)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	buf = binary.AppendUvarint(buf, uint64(len(rendered)))
+
+	return append(buf, rendered...)
+}
+
+func (ce *consoleEncoder) appendLine(buf []byte, e entry) []byte {
+	fields := decodeConsoleFields(decodeConsoleFields(nil, e.extra), e.fields)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return bytes.Compare(fields[i].key, fields[j].key) < 0
+	})
+
+	buf = ce.appendColored(buf, colorGray, func(buf []byte) []byte {
+		return e.time.AppendFormat(buf, consoleTimeFormat)
+	})
+	buf = append(buf, ' ')
+	buf = ce.appendColored(buf, levelColor(e.level), func(buf []byte) []byte {
+		buf = append(buf, strings.ToUpper(string(e.level))...)
+		for i := len(e.level); i < consoleLevelWidth; i++ {
+			buf = append(buf, ' ')
+		}
+		return buf
+	})
+	buf = append(buf, ' ')
+	buf = append(buf, e.message...)
+
+	var multi []consoleField
+	padded := false
+	for i, f := range fields {
+		if i+1 < len(fields) && bytes.Equal(f.key, fields[i+1].key) {
+			// the last value of a key wins
+			continue
+		}
+		if bytes.IndexByte(f.value, '\n') != -1 {
+			multi = append(multi, f)
+			continue
+		}
+		if !padded {
+			for i := len(e.message); i < consoleMessageWidth; i++ {
+				buf = append(buf, ' ')
+			}
+		}
+		padded = true
+		buf = append(buf, ' ')
+		buf = ce.appendPair(buf, f, f.value)
+	}
+	for _, f := range multi {
+		indent := "\n" + consoleMultiIndent + strings.Repeat(" ", len(f.key)+1)
+		buf = append(buf, "\n"+consoleMultiIndent...)
+		value := bytes.ReplaceAll(bytes.TrimRight(f.value, "\n"), []byte("\n"), []byte(indent))
+		buf = ce.appendPair(buf, f, value)
+	}
+
+	return append(buf, '\n')
+}
+
+func (ce *consoleEncoder) appendPair(buf []byte, f consoleField, value []byte) []byte {
+	if f.isErr || string(f.key) == "error" || bytes.HasPrefix(f.key, []byte("error.")) {
+		return ce.appendColored(buf, colorRed+colorBold, func(buf []byte) []byte {
+			buf = append(buf, f.key...)
+			buf = append(buf, '=')
+			return append(buf, value...)
+		})
+	}
+	buf = ce.appendColored(buf, colorGray, func(buf []byte) []byte {
+		buf = append(buf, f.key...)
+		return append(buf, '=')
+	})
+
+	return append(buf, value...)
+}
+
+func (ce *consoleEncoder) appendColored(buf []byte, color string, appendText func([]byte) []byte) []byte {
+	if !ce.color {
+		return appendText(buf)
+	}
+	buf = append(buf, color...)
+	buf = appendText(buf)
+
+	return append(buf, colorReset...)
+}
+
+// decodeConsoleFields appends the records in b to fields.
+func decodeConsoleFields(fields []consoleField, b []byte) []consoleField {
+	for len(b) > 0 {
+		var f consoleField
+		f.isErr = b[0] == 1
+		b = b[1:]
+		n, size := binary.Uvarint(b)
+		f.key, b = b[size:size+int(n)], b[size+int(n):]
+		n, size = binary.Uvarint(b)
+		f.value, b = b[size:size+int(n)], b[size+int(n):]
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func levelColor(level LogLevel) string {
+	switch level {
+	case LevelDebug:
+		return colorGray
+	case LevelError:
+		return colorRed
+	default:
+		return colorBlue
+	}
+}
+
+func consoleValue(value interface{}) string {
+	s, ok := textValue(value)
+	if !ok {
+		return "<nil>"
+	}
+	if s == "" || (!strings.Contains(s, "\n") && strings.ContainsAny(s, " \t\"=")) {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
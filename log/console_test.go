@@ -0,0 +1,52 @@
+package log_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestConsoleFormat(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw, log.WithFormat(log.FormatConsole), log.WithColor(false))
+
+	logger.With(log.Fields{
+		"b":     "two words",
+		"a":     1,
+		"stack": "first\nsecond",
+	}).WithErr(errors.New("failed")).Error("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	lines := strings.Split(strings.TrimSuffix(tw.LogLines[0], "\n"), "\n")
+	test.Equals(t, 3, len(lines))
+	test.Includes(t, " ERROR message ", lines[0])
+	test.Includes(t, ` a=1 b="two words" error=failed`, lines[0])
+	test.Equals(t, "    stack=first", lines[1])
+	test.Equals(t, "          second", lines[2])
+	test.NotIncludes(t, "\x1b[", tw.LogLines[0])
+}
+
+func TestConsoleFormatColor(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw, log.WithFormat(log.FormatConsole), log.WithColor(true))
+
+	logger.WithErr(errors.New("failed")).Error("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	test.Includes(t, "\x1b[31mERROR\x1b[0m", tw.LogLines[0])
+	test.Includes(t, "\x1b[31m\x1b[1merror=failed\x1b[0m", tw.LogLines[0])
+}
+
+func TestConsoleFormatNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw, log.WithFormat(log.FormatConsole))
+
+	logger.Info("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	test.NotIncludes(t, "\x1b[", tw.LogLines[0])
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	fieldsKey
+	spanKey
+)
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = New(os.Stderr)
+)
+
+// Default returns the logger that FromContext falls back to when no logger
+// is stored in the context. Unless changed with SetDefault, it writes to
+// stderr at level info.
+func Default() Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultLogger
+}
+
+func SetDefault(logger Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultLogger = logger
+}
+
+// NewContext returns a copy of ctx that carries logger.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger if
+// there is none, with all fields that were added to ctx attached. If ctx
+// carries a span context, its IDs are added as trace_id and span_id.
+func FromContext(ctx context.Context) Logger {
+	logger, ok := ctx.Value(loggerKey).(Logger)
+	if !ok {
+		logger = Default()
+	}
+	if fields, ok := ctx.Value(fieldsKey).(Fields); ok {
+		logger = logger.With(fields)
+	}
+	if sc, ok := SpanFromContext(ctx); ok && sc.IsValid() {
+		logger = logger.With(traceFields(sc))
+	}
+
+	return logger
+}
+
+// ContextWithFields returns a copy of ctx that carries the given fields
+// in addition to the fields already present.
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	newFields := make(Fields)
+	if current, ok := ctx.Value(fieldsKey).(Fields); ok {
+		for k, v := range current {
+			newFields[k] = v
+		}
+	}
+	for k, v := range fields {
+		newFields[k] = v
+	}
+
+	return context.WithValue(ctx, fieldsKey, newFields)
+}
+
+func ContextWithField(ctx context.Context, key string, value interface{}) context.Context {
+	return ContextWithFields(ctx, Fields{
+		key: value,
+	})
+}
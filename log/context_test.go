@@ -0,0 +1,66 @@
+package log_test
+
+import (
+	"context"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestContext(t *testing.T) {
+	message := "message"
+	out := log.NewTestOut()
+	logger := log.NewTestLogger(out)
+
+	t.Run("logger", func(t *testing.T) {
+		out.Flush()
+		ctx := log.NewContext(context.Background(), logger.WithField("key", "value"))
+		log.FromContext(ctx).Info(message)
+
+		test.Equals(t, []log.TestLine{{
+			Level:   log.LevelInfo,
+			Message: message,
+			Fields:  log.Fields{"key": "value"},
+		}}, out.Lines)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		out.Flush()
+		ctx := log.ContextWithField(context.Background(), "request_id", "abc")
+		ctx = log.NewContext(ctx, logger)
+		ctx = log.ContextWithFields(ctx, log.Fields{"user_id": 3})
+		log.FromContext(ctx).Info(message)
+
+		test.Equals(t, []log.TestLine{{
+			Level:   log.LevelInfo,
+			Message: message,
+			Fields: log.Fields{
+				"request_id": "abc",
+				"user_id":    3,
+			},
+		}}, out.Lines)
+	})
+
+	t.Run("fields do not leak to parent", func(t *testing.T) {
+		out.Flush()
+		parent := log.NewContext(log.ContextWithField(context.Background(), "a", 1), logger)
+		log.ContextWithField(parent, "b", 2)
+		log.FromContext(parent).Info(message)
+
+		test.Equals(t, 1, len(out.Lines))
+		test.Equals(t, log.Fields{"a": 1}, out.Lines[0].Fields)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		out.Flush()
+		current := log.Default()
+		defer log.SetDefault(current)
+
+		log.SetDefault(logger)
+		log.FromContext(context.Background()).Error(message)
+
+		test.Equals(t, 1, len(out.Lines))
+		test.Equals(t, log.LevelError, out.Lines[0].Level)
+	})
+}
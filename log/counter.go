@@ -0,0 +1,229 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	counterMetric    = "log_lines_total"
+	counterMaxValues = 100
+	counterOther     = "other"
+)
+
+var counterLevels = []LogLevel{LevelDebug, LevelInfo, LevelError}
+
+type counterKey struct {
+	level LogLevel
+	value string
+}
+
+type counterState struct {
+	mu     sync.Mutex
+	field  string
+	counts map[counterKey]uint64
+	values map[string]bool
+}
+
+// Counter is a Logger that counts the lines that are written, per level,
+// and passes them on to the wrapped logger. The counts are served over HTTP
+// in the Prometheus text format, see ServeHTTP.
+type Counter struct {
+	logger   Logger
+	group    string
+	name     string
+	value    interface{}
+	hasValue bool
+	state    *counterState
+}
+
+// NewCounter returns a Counter for logger. If field is not empty, the lines
+// are also counted per value of that field, which becomes a label of the
+// metric. Use "logger" to count per name. To keep the number of series
+// bounded, values beyond the first 100 are counted as "other".
+func NewCounter(logger Logger, field string) *Counter {
+	return &Counter{
+		logger: logger,
+		state: &counterState{
+			field:  field,
+			counts: make(map[counterKey]uint64),
+			values: make(map[string]bool),
+		},
+	}
+}
+
+func (c *Counter) SetLogLevel(loglevel LogLevel) {
+	c.logger.SetLogLevel(loglevel)
+}
+
+func (c *Counter) Named(name string) Logger {
+	nc := c.derive(c.logger.Named(name), nil)
+	nc.name = joinName(c.name, name)
+	if c.state.field == nameKey {
+		nc.value, nc.hasValue = nc.name, true
+	}
+
+	return nc
+}
+
+func (c *Counter) WithGroup(name string) Logger {
+	nc := c.derive(c.logger.WithGroup(name), nil)
+	if name != "" {
+		nc.group = c.group + name + "."
+	}
+
+	return nc
+}
+
+func (c *Counter) WithField(key string, value interface{}) Logger {
+	return c.derive(c.logger.WithField(key, value), Fields{key: value})
+}
+
+func (c *Counter) WithErr(err error) Logger {
+	return c.derive(c.logger.WithErr(err), Fields{"error": err})
+}
+
+func (c *Counter) With(fields Fields) Logger {
+	return c.derive(c.logger.With(fields), fields)
+}
+
+func (c *Counter) Enabled(level LogLevel) bool {
+	return c.logger.Enabled(level)
+}
+
+func (c *Counter) Debug(message string) {
+	c.logger.Debug(message)
+	c.count(LevelDebug)
+}
+
+func (c *Counter) Info(message string) {
+	c.logger.Info(message)
+	c.count(LevelInfo)
+}
+
+func (c *Counter) Error(message string) {
+	c.logger.Error(message)
+	c.count(LevelError)
+}
+
+// ServeHTTP writes the counts in the Prometheus text exposition format, as
+// the counter log_lines_total with a level label and, if a field was given,
+// a label named after the field.
+func (c *Counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(c.state.exposition()))
+}
+
+// derive returns a Counter for logger. Only the value of the counted field
+// is kept from fields.
+func (c *Counter) derive(logger Logger, fields Fields) *Counter {
+	nc := &Counter{
+		logger:   logger,
+		group:    c.group,
+		name:     c.name,
+		value:    c.value,
+		hasValue: c.hasValue,
+		state:    c.state,
+	}
+	for k, v := range fields {
+		if c.state.field != "" && c.group+k == c.state.field {
+			nc.value, nc.hasValue = v, true
+		}
+	}
+
+	return nc
+}
+
+func (c *Counter) count(level LogLevel) {
+	if !c.logger.Enabled(level) {
+		return
+	}
+
+	var value string
+	if c.hasValue {
+		v := c.value
+		if lv, ok := v.(LazyValue); ok {
+			v = lv()
+		}
+		value, _ = textValue(v)
+	}
+	c.state.add(level, value)
+}
+
+func (cs *counterState) add(level LogLevel, value string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.field != "" && !cs.values[value] {
+		if len(cs.values) >= counterMaxValues {
+			value = counterOther
+		}
+		cs.values[value] = true
+	}
+	cs.counts[counterKey{level: level, value: value}]++
+}
+
+func (cs *counterState) exposition() string {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# HELP %s Number of log lines written, by level.\n", counterMetric)
+	fmt.Fprintf(b, "# TYPE %s counter\n", counterMetric)
+	if cs.field == "" {
+		for _, level := range counterLevels {
+			fmt.Fprintf(b, "%s{level=%q} %d\n", counterMetric, level, cs.counts[counterKey{level: level}])
+		}
+		return b.String()
+	}
+
+	values := make([]string, 0, len(cs.values))
+	for v := range cs.values {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	label := counterLabel(cs.field)
+	for _, level := range counterLevels {
+		for _, v := range values {
+			n, ok := cs.counts[counterKey{level: level, value: v}]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(b, "%s{level=%q,%s=\"%s\"} %d\n", counterMetric, level, label, escapeLabelValue(v), n)
+		}
+	}
+
+	return b.String()
+}
+
+// counterLabel turns a field name into a valid Prometheus label name.
+func counterLabel(field string) string {
+	b := []byte(field)
+	for i, c := range b {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		b[i] = '_'
+	}
+	label := string(b)
+	if label == "level" || strings.HasPrefix(label, "__") {
+		label = "field_" + label
+	}
+
+	return label
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
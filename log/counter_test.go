@@ -0,0 +1,117 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	test.Equals(t, http.StatusOK, rec.Code)
+	test.Includes(t, "text/plain", rec.Header().Get("Content-Type"))
+
+	return rec.Body.String()
+}
+
+func TestCounter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := log.New(buf)
+	logger.SetLogLevel(log.LevelInfo)
+	counter := log.NewCounter(logger, "")
+
+	l := counter.Named("api").WithField("a", 1)
+	l.Debug("skipped")
+	l.Info("one")
+	l.Info("two")
+	l.Error("three")
+
+	test.Equals(t, 3, strings.Count(buf.String(), "\n"))
+	test.Equals(t, `# HELP log_lines_total Number of log lines written, by level.
+# TYPE log_lines_total counter
+log_lines_total{level="debug"} 0
+log_lines_total{level="info"} 2
+log_lines_total{level="error"} 1
+`, scrape(t, counter))
+}
+
+func TestCounterField(t *testing.T) {
+	counter := log.NewCounter(log.NewTestLogger(log.NewTestOut()), "http.component")
+
+	counter.Info("no component")
+	api := counter.WithGroup("http").WithField("component", "api")
+	api.Info("one")
+	api.Error("two")
+	api.WithField("other", true).Error("three")
+	counter.With(log.Fields{"http.component": `say "hi"`}).Info("four")
+	counter.WithField("http.component", log.LazyValue(func() interface{} { return "lazy" })).Info("five")
+
+	body := scrape(t, counter)
+	for _, exp := range []string{
+		`log_lines_total{level="info",http_component=""} 1`,
+		`log_lines_total{level="info",http_component="api"} 1`,
+		`log_lines_total{level="error",http_component="api"} 2`,
+		`log_lines_total{level="info",http_component="say \"hi\""} 1`,
+		`log_lines_total{level="info",http_component="lazy"} 1`,
+	} {
+		test.Includes(t, exp, body)
+	}
+	test.NotIncludes(t, `level="debug"`, body)
+}
+
+func TestCounterName(t *testing.T) {
+	counter := log.NewCounter(log.NewTestLogger(log.NewTestOut()), "logger")
+	counter.Named("api").Named("users").Info("one")
+
+	test.Includes(t, `log_lines_total{level="info",logger="api.users"} 1`, scrape(t, counter))
+}
+
+func TestCounterMaxValues(t *testing.T) {
+	counter := log.NewCounter(log.NewTestLogger(log.NewTestOut()), "id")
+	for i := 0; i < 150; i++ {
+		counter.WithField("id", i).Info("line")
+	}
+
+	body := scrape(t, counter)
+	test.Includes(t, `log_lines_total{level="info",id="other"} 50`, body)
+	test.Equals(t, 103, strings.Count(body, "\n"))
+}
+
+func TestCounterMethod(t *testing.T) {
+	counter := log.NewCounter(log.NewTestLogger(log.NewTestOut()), "")
+	rec := httptest.NewRecorder()
+	counter.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	test.Equals(t, http.StatusMethodNotAllowed, rec.Code)
+	test.Equals(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	counter := log.NewCounter(log.New(io.Discard), "worker")
+	done := make(chan bool)
+	for w := 0; w < 4; w++ {
+		go func(w int) {
+			l := counter.WithField("worker", w)
+			for i := 0; i < 100; i++ {
+				l.Info("work")
+			}
+			done <- true
+		}(w)
+	}
+	for w := 0; w < 4; w++ {
+		<-done
+	}
+
+	body := scrape(t, counter)
+	for w := 0; w < 4; w++ {
+		test.Includes(t, fmt.Sprintf(`log_lines_total{level="info",worker="%d"} 100`, w), body)
+	}
+}
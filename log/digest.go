@@ -0,0 +1,274 @@
+package log
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+const digestTimeFormat = "2006-01-02 15:04:05"
+
+// Mailer sends an email. It is implemented by smtp.SSLSMTP.
+type Mailer interface {
+	Send(from, to mail.Address, subject, body string) error
+}
+
+// DigestConfig configures a Digest. At most one mail is sent per Interval,
+// the first one right after the first error. A mail lists at most MaxBatch
+// different messages, further ones are only counted. Interval defaults to
+// five minutes, MaxBatch to 100 and Subject to "Error digest".
+type DigestConfig struct {
+	From     mail.Address
+	To       mail.Address
+	Subject  string
+	Interval time.Duration
+	MaxBatch int
+}
+
+type digestKey struct {
+	name    string
+	message string
+}
+
+type digestEntry struct {
+	key    digestKey
+	fields Fields
+	count  int
+	first  time.Time
+	last   time.Time
+}
+
+type digestState struct {
+	mu       sync.Mutex
+	sendMu   sync.Mutex
+	config   DigestConfig
+	mailer   Mailer
+	logger   Logger
+	entries  []*digestEntry
+	index    map[digestKey]*digestEntry
+	omitted  int
+	lastSent time.Time
+	timer    *time.Timer
+	closed   bool
+}
+
+// Digest is a Logger that collects the error lines and mails them as a
+// digest, with identical messages grouped together. Every line is passed
+// to the wrapped logger as well. Close sends what is still pending.
+type Digest struct {
+	logger Logger
+	name   string
+	group  string
+	fields Fields
+	state  *digestState
+}
+
+func NewDigest(logger Logger, mailer Mailer, config DigestConfig) *Digest {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.MaxBatch <= 0 {
+		config.MaxBatch = 100
+	}
+	if config.Subject == "" {
+		config.Subject = "Error digest"
+	}
+
+	return &Digest{
+		logger: logger,
+		fields: Fields{},
+		state: &digestState{
+			config: config,
+			mailer: mailer,
+			logger: logger,
+			index:  make(map[digestKey]*digestEntry),
+		},
+	}
+}
+
+func (d *Digest) SetLogLevel(loglevel LogLevel) {
+	d.logger.SetLogLevel(loglevel)
+}
+
+func (d *Digest) Named(name string) Logger {
+	nd := d.derive(d.logger.Named(name), nil)
+	nd.name = joinName(d.name, name)
+
+	return nd
+}
+
+func (d *Digest) WithGroup(name string) Logger {
+	nd := d.derive(d.logger.WithGroup(name), nil)
+	if name != "" {
+		nd.group = d.group + name + "."
+	}
+
+	return nd
+}
+
+func (d *Digest) WithField(key string, value interface{}) Logger {
+	return d.derive(d.logger.WithField(key, value), Fields{key: value})
+}
+
+func (d *Digest) WithErr(err error) Logger {
+	return d.derive(d.logger.WithErr(err), Fields{"error": err})
+}
+
+func (d *Digest) With(fields Fields) Logger {
+	return d.derive(d.logger.With(fields), fields)
+}
+
+func (d *Digest) Enabled(level LogLevel) bool {
+	return d.logger.Enabled(level)
+}
+
+func (d *Digest) Debug(message string) {
+	d.logger.Debug(message)
+}
+
+func (d *Digest) Info(message string) {
+	d.logger.Info(message)
+}
+
+func (d *Digest) Error(message string) {
+	d.logger.Error(message)
+	if d.logger.Enabled(LevelError) {
+		d.state.add(digestKey{name: d.name, message: message}, resolveFields(d.fields), time.Now())
+	}
+}
+
+// Flush sends the collected entries right away, regardless of the
+// interval.
+func (d *Digest) Flush() error {
+	return d.state.send()
+}
+
+// Close sends the collected entries. Errors that are logged after Close
+// are only passed to the wrapped logger.
+func (d *Digest) Close() error {
+	d.state.mu.Lock()
+	d.state.closed = true
+	if d.state.timer != nil {
+		d.state.timer.Stop()
+		d.state.timer = nil
+	}
+	d.state.mu.Unlock()
+
+	return d.state.send()
+}
+
+// derive returns a Digest for logger with fields added to the ones of d.
+// The fields are kept to show them with the first occurrence of a message.
+func (d *Digest) derive(logger Logger, fields Fields) *Digest {
+	newFields := make(Fields, len(d.fields)+len(fields))
+	for k, v := range d.fields {
+		newFields[k] = v
+	}
+	for k, v := range fields {
+		newFields[d.group+k] = v
+	}
+
+	return &Digest{
+		logger: logger,
+		name:   d.name,
+		group:  d.group,
+		fields: newFields,
+		state:  d.state,
+	}
+}
+
+// add counts an occurrence of key and makes sure a mail is scheduled.
+func (ds *digestState) add(key digestKey, fields Fields, now time.Time) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if ds.closed {
+		return
+	}
+	e, ok := ds.index[key]
+	switch {
+	case ok:
+		e.count++
+		e.last = now
+	case len(ds.entries) >= ds.config.MaxBatch:
+		ds.omitted++
+	default:
+		e = &digestEntry{
+			key:    key,
+			fields: fields,
+			count:  1,
+			first:  now,
+			last:   now,
+		}
+		ds.entries = append(ds.entries, e)
+		ds.index[key] = e
+	}
+
+	if ds.timer == nil {
+		wait := ds.lastSent.Add(ds.config.Interval).Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		ds.timer = time.AfterFunc(wait, func() {
+			if err := ds.send(); err != nil {
+				ds.logger.WithErr(err).Error("could not send error digest")
+			}
+		})
+	}
+}
+
+// send mails the collected entries, if there are any.
+func (ds *digestState) send() error {
+	ds.sendMu.Lock()
+	defer ds.sendMu.Unlock()
+
+	ds.mu.Lock()
+	entries, omitted := ds.entries, ds.omitted
+	ds.entries, ds.omitted = nil, 0
+	ds.index = make(map[digestKey]*digestEntry)
+	if ds.timer != nil {
+		ds.timer.Stop()
+		ds.timer = nil
+	}
+	if len(entries) > 0 || omitted > 0 {
+		ds.lastSent = time.Now()
+	}
+	ds.mu.Unlock()
+
+	if len(entries) == 0 && omitted == 0 {
+		return nil
+	}
+
+	total := omitted
+	for _, e := range entries {
+		total += e.count
+	}
+	subject := fmt.Sprintf("%s: %d errors", ds.config.Subject, total)
+
+	return ds.mailer.Send(ds.config.From, ds.config.To, subject, digestBody(entries, omitted))
+}
+
+func digestBody(entries []*digestEntry, omitted int) string {
+	body := &strings.Builder{}
+	for _, e := range entries {
+		fmt.Fprintf(body, "%dx %s\n", e.count, e.key.message)
+		if e.key.name != "" {
+			fmt.Fprintf(body, "    logger: %s\n", e.key.name)
+		}
+		fmt.Fprintf(body, "    first: %s\n", e.first.Format(digestTimeFormat))
+		if e.count > 1 {
+			fmt.Fprintf(body, "    last: %s\n", e.last.Format(digestTimeFormat))
+		}
+		for _, k := range sortedKeys(e.fields) {
+			fmt.Fprintf(body, "    %s: %v\n", k, e.fields[k])
+		}
+		body.WriteString("\n")
+	}
+	if omitted > 0 {
+		fmt.Fprintf(body, "%d more errors with other messages were omitted.\n", omitted)
+	}
+
+	return body.String()
+}
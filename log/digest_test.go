@@ -0,0 +1,136 @@
+package log_test
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/smtp"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+var _ log.Mailer = &smtp.SSLSMTP{}
+
+type testMail struct {
+	From    mail.Address
+	To      mail.Address
+	Subject string
+	Body    string
+}
+
+type testMailer struct {
+	mu    sync.Mutex
+	mails chan testMail
+	err   error
+}
+
+func newTestMailer() *testMailer {
+	return &testMailer{
+		mails: make(chan testMail, 10),
+	}
+}
+
+func (tm *testMailer) Send(from, to mail.Address, subject, body string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.mails <- testMail{From: from, To: to, Subject: subject, Body: body}
+
+	return tm.err
+}
+
+func (tm *testMailer) wait(t *testing.T) testMail {
+	t.Helper()
+	select {
+	case m := <-tm.mails:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no mail sent")
+		return testMail{}
+	}
+}
+
+func TestDigest(t *testing.T) {
+	out := log.NewTestOut()
+	mailer := newTestMailer()
+	from := mail.Address{Name: "app", Address: "app@example.com"}
+	to := mail.Address{Address: "ops@example.com"}
+	digest := log.NewDigest(log.NewTestLogger(out), mailer, log.DigestConfig{
+		From:     from,
+		To:       to,
+		Interval: time.Hour,
+	})
+	logger := digest.Named("db").WithField("host", "db1")
+
+	logger.Error("first")
+	first := mailer.wait(t)
+	test.Equals(t, from, first.From)
+	test.Equals(t, to, first.To)
+	test.Equals(t, "Error digest: 1 errors", first.Subject)
+	test.Includes(t, "1x first\n    logger: db\n", first.Body)
+	test.Includes(t, "    host: db1\n", first.Body)
+
+	for i := 0; i < 3; i++ {
+		logger.Error("repeated")
+	}
+	logger.WithErr(errors.New("failed")).Error("other")
+	logger.Info("info")
+	test.Equals(t, 0, len(mailer.mails))
+
+	test.OK(t, digest.Close())
+	second := mailer.wait(t)
+	test.Equals(t, "Error digest: 4 errors", second.Subject)
+	test.Includes(t, "3x repeated\n", second.Body)
+	test.Includes(t, "1x other\n", second.Body)
+	test.Includes(t, "    error: failed\n", second.Body)
+	test.NotIncludes(t, "info", second.Body)
+
+	logger.Error("after close")
+	test.OK(t, digest.Flush())
+	test.Equals(t, 0, len(mailer.mails))
+	test.Equals(t, 7, len(out.Lines))
+}
+
+func TestDigestMaxBatch(t *testing.T) {
+	mailer := newTestMailer()
+	digest := log.NewDigest(log.NewTestLogger(log.NewTestOut()), mailer, log.DigestConfig{
+		Interval: time.Hour,
+		MaxBatch: 2,
+	})
+	digest.Error("start")
+	mailer.wait(t)
+
+	for _, message := range []string{"a", "b", "c", "a", "d"} {
+		digest.Error(message)
+	}
+	test.OK(t, digest.Flush())
+
+	m := mailer.wait(t)
+	test.Equals(t, "Error digest: 5 errors", m.Subject)
+	test.Includes(t, "2x a\n", m.Body)
+	test.Includes(t, "1x b\n", m.Body)
+	test.Assert(t, !strings.Contains(m.Body, "1x c"), "unexpected message", m.Body)
+	test.Includes(t, "2 more errors with other messages were omitted.", m.Body)
+}
+
+func TestDigestSendError(t *testing.T) {
+	sw := &syncWriter{}
+	mailer := newTestMailer()
+	mailer.err = errors.New("connection refused")
+	digest := log.NewDigest(log.New(sw), mailer, log.DigestConfig{})
+
+	digest.Error("failed")
+	mailer.wait(t)
+
+	var logged bool
+	for i := 0; i < 100 && !logged; i++ {
+		time.Sleep(10 * time.Millisecond)
+		logged = strings.Contains(sw.String(), "could not send error digest")
+	}
+	test.Assert(t, logged, "send error was not logged", sw.String())
+	test.Includes(t, "connection refused", sw.String())
+}
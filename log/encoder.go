@@ -0,0 +1,273 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	hexDigits     = "0123456789abcdef"
+	maxPooledSize = 64 << 10
+)
+
+// entry holds everything that is written for a single line. The fields
+// are already encoded by the encoder that writes the line, extra holds the
+// fields that are determined per line, like the caller.
+type entry struct {
+	time    time.Time
+	level   LogLevel
+	message string
+	extra   []byte
+	fields  []byte
+}
+
+// encoder turns entries into lines of a specific format. Fields are encoded
+// separately with appendField, so that the fields of a logger can be
+// encoded once, for the first line, instead of on every line.
+type encoder interface {
+	appendField(buf []byte, key string, value interface{}) []byte
+	appendLine(buf []byte, e entry) []byte
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledSize {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
+
+// appendQuoted appends s as a double quoted string with the escaping of
+// encoding/json, except that HTML characters are left as they are.
+func appendQuoted(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '\\' && b != '"' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+
+	return append(buf, '"')
+}
+
+// appendNumber appends value if it is one of the number or bool types and
+// reports whether it did.
+func appendNumber(buf []byte, value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(buf, v, 10), true
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), true
+	case float32:
+		return appendFloat(buf, float64(v), 32)
+	case float64:
+		return appendFloat(buf, v, 64)
+	case bool:
+		return strconv.AppendBool(buf, v), true
+	default:
+		return buf, false
+	}
+}
+
+// appendFloat formats like encoding/json does. NaN and infinities are not
+// numbers in JSON, they are left to the caller.
+func appendFloat(buf []byte, f float64, bits int) ([]byte, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return buf, false
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21)) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+
+	return buf, true
+}
+
+// textValue returns the text representation of value for formats that
+// have no types. The second result is false for nil values.
+func textValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case error:
+		return safeText(value, v.Error)
+	case fmt.Stringer:
+		return safeText(value, v.String)
+	default:
+		return fmt.Sprintf("%+v", v), true
+	}
+}
+
+// appendJSONValue appends value as JSON. Errors and fmt.Stringers that
+// have no JSON representation of their own are written as strings. Other
+// types are left to encoding/json.
+func appendJSONValue(buf []byte, value interface{}) []byte {
+	if b, ok := appendNumber(buf, value); ok {
+		return b
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendQuoted(buf, v)
+	case time.Time:
+		buf = append(buf, '"')
+		buf = v.AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	case map[string]interface{}:
+		return appendJSONObject(buf, v)
+	case Fields:
+		return appendJSONObject(buf, v)
+	case []interface{}:
+		buf = append(buf, '[')
+		for i, item := range v {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONValue(buf, item)
+		}
+		return append(buf, ']')
+	case json.Marshaler, encoding.TextMarshaler:
+		// encoding/json knows how to write these
+	case error, fmt.Stringer:
+		s, ok := textValue(v)
+		if !ok {
+			return append(buf, "null"...)
+		}
+		return appendQuoted(buf, s)
+	}
+
+	b := bytes.NewBuffer(buf)
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		s, ok := textValue(value)
+		if !ok {
+			return append(buf, "null"...)
+		}
+		return appendQuoted(buf, s)
+	}
+
+	// drop the newline that Encode adds
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+func appendJSONObject[V any](buf []byte, m map[string]V) []byte {
+	buf = append(buf, '{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendQuoted(buf, k)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, m[k])
+	}
+
+	return append(buf, '}')
+}
+
+// safeText calls f and recovers from panics, like those of methods that are
+// called on a nil pointer.
+func safeText(value interface{}, f func() string) (s string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				s, ok = "", false
+				return
+			}
+			s, ok = fmt.Sprintf("PANIC in value: %v", r), true
+		}
+	}()
+
+	return f(), true
+}
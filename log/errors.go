@@ -0,0 +1,167 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrorWithFields can be implemented by errors that carry structured data.
+// The fields are included when the error is logged with WithErr.
+type ErrorWithFields interface {
+	error
+	LogFields() Fields
+}
+
+// errorValue marks an error that is added with WithErr, so that it is
+// expanded only when a line is actually written. If redact is set, it is
+// applied to the expanded error, see RedactingLogger.
+type errorValue struct {
+	err    error
+	redact func(map[string]interface{})
+}
+
+// expand returns the expanded error, redacted if needed.
+func (ev errorValue) expand() map[string]interface{} {
+	m := expandError(ev.err)
+	if m != nil && ev.redact != nil {
+		ev.redact(m)
+	}
+
+	return m
+}
+
+// LogValue lets slog write the expanded error.
+func (ev errorValue) LogValue() slog.Value {
+	return slog.AnyValue(ev.expand())
+}
+
+// expandError describes err as a map with its message and type, the fields
+// of ErrorWithFields and the errors it wraps. Wrapped errors are listed in
+// "chain", the errors of a join are listed in "causes" of the joining
+// error. If any error in the chain has a StackTrace method, like the errors
+// of github.com/pkg/errors, the innermost trace is added as "stack". A nil
+// pointer whose Error method panics counts as a nil error.
+func expandError(err error) map[string]interface{} {
+	if err == nil {
+		return nil
+	}
+
+	m := errorEntry(err)
+	if m == nil {
+		return nil
+	}
+	stack := errorStack(err)
+	var chain []interface{}
+	last := m
+	for e := err; ; {
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			var causes []interface{}
+			for _, cause := range joined.Unwrap() {
+				if cause != nil {
+					causes = append(causes, expandError(cause))
+				}
+			}
+			if len(causes) > 0 {
+				last["causes"] = causes
+			}
+			break
+		}
+		wrapper, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		if e = wrapper.Unwrap(); e == nil {
+			break
+		}
+		entry := errorEntry(e)
+		if entry == nil {
+			break
+		}
+		last = entry
+		chain = append(chain, last)
+		if s := errorStack(e); s != "" {
+			stack = s
+		}
+	}
+	if len(chain) > 0 {
+		m["chain"] = chain
+	}
+	if stack != "" {
+		m["stack"] = stack
+	}
+
+	return m
+}
+
+// errorEntry returns the message, type and fields of err, or nil if err is
+// a nil pointer that can not produce a message.
+func errorEntry(err error) map[string]interface{} {
+	message, ok := safeText(err, err.Error)
+	if !ok {
+		return nil
+	}
+	m := map[string]interface{}{
+		"message": message,
+		"type":    reflect.TypeOf(err).String(),
+	}
+	if ef, ok := err.(ErrorWithFields); ok {
+		if fields := errorFields(ef); len(fields) > 0 {
+			m["fields"] = map[string]interface{}(fields)
+		}
+	}
+
+	return m
+}
+
+func errorFields(ef ErrorWithFields) (fields Fields) {
+	defer func() {
+		if recover() != nil {
+			fields = nil
+		}
+	}()
+
+	return ef.LogFields()
+}
+
+// errorStack returns the formatted result of a StackTrace method of err, if
+// it has one.
+func errorStack(err error) (stack string) {
+	defer func() {
+		if recover() != nil {
+			stack = ""
+		}
+	}()
+
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%+v", method.Call(nil)[0].Interface()))
+}
+
+// flatten appends nested maps and slices in value as separate keys with a
+// dotted path, for formats that cannot represent nesting.
+func flatten(buf []byte, enc encoder, key string, value interface{}) []byte {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			buf = flatten(buf, enc, key+"."+k, v[k])
+		}
+	case Fields:
+		for _, k := range sortedKeys(v) {
+			buf = flatten(buf, enc, key+"."+k, v[k])
+		}
+	case []interface{}:
+		for i, item := range v {
+			buf = flatten(buf, enc, key+"."+strconv.Itoa(i), item)
+		}
+	default:
+		buf = enc.appendField(buf, key, value)
+	}
+
+	return buf
+}
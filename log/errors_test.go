@@ -0,0 +1,129 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+type testFieldsError struct {
+	id int
+}
+
+func (tfe *testFieldsError) Error() string {
+	return "not found"
+}
+
+func (tfe *testFieldsError) LogFields() log.Fields {
+	return log.Fields{"id": tfe.id}
+}
+
+type testStack []string
+
+func (ts testStack) Format(s fmt.State, verb rune) {
+	for _, frame := range ts {
+		fmt.Fprintf(s, "\n%s", frame)
+	}
+}
+
+type testStackError struct {
+	err   error
+	stack testStack
+}
+
+func (tse *testStackError) Error() string {
+	return tse.err.Error()
+}
+
+func (tse *testStackError) Unwrap() error {
+	return tse.err
+}
+
+func (tse *testStackError) StackTrace() testStack {
+	return tse.stack
+}
+
+func TestGoKitIOLoggerWithErrExpanded(t *testing.T) {
+	fieldsErr := &testFieldsError{id: 3}
+	stackErr := &testStackError{
+		err:   fieldsErr,
+		stack: testStack{"main.find", "main.main"},
+	}
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		exp  string
+	}{
+		{
+			name: "plain",
+			err:  errors.New("failed"),
+			exp:  `{"message":"failed","type":"*errors.errorString"}`,
+		},
+		{
+			name: "fields",
+			err:  fieldsErr,
+			exp:  `{"fields":{"id":3},"message":"not found","type":"*log_test.testFieldsError"}`,
+		},
+		{
+			name: "chain with stack",
+			err:  fmt.Errorf("get user: %w", stackErr),
+			exp: `{"chain":[` +
+				`{"message":"not found","type":"*log_test.testStackError"},` +
+				`{"fields":{"id":3},"message":"not found","type":"*log_test.testFieldsError"}` +
+				`],"message":"get user: not found","stack":"main.find\nmain.main","type":"*fmt.wrapError"}`,
+		},
+		{
+			name: "join",
+			err:  fmt.Errorf("close: %w", errors.Join(errors.New("a"), fmt.Errorf("b: %w", errors.New("c")))),
+			exp: `{"chain":[{"causes":[` +
+				`{"message":"a","type":"*errors.errorString"},` +
+				`{"chain":[{"message":"c","type":"*errors.errorString"}],"message":"b: c","type":"*fmt.wrapError"}` +
+				`],"message":"a\nb: c","type":"*errors.joinError"}],"message":"close: a\nb: c","type":"*fmt.wrapError"}`,
+		},
+		{
+			name: "nil pointer",
+			err:  (*testStackError)(nil),
+			exp:  `null`,
+		},
+		{
+			name: "wrapped nil pointer",
+			err:  fmt.Errorf("find: %w", (*testStackError)(nil)),
+			exp:  `{"message":"find: <nil>","type":"*fmt.wrapError"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWriter{}
+			log.NewGoKitIOLogger(tw).WithErr(tc.err).Error("message")
+
+			test.Equals(t, 1, len(tw.LogLines))
+			var line struct {
+				Error json.RawMessage `json:"error"`
+			}
+			test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &line))
+			test.Equals(t, tc.exp, string(line.Error))
+		})
+	}
+}
+
+func TestGoKitIOLoggerWithErrFlattened(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw, log.WithFormat(log.FormatLogfmt))
+
+	logger.WithErr(fmt.Errorf("get user: %w", &testFieldsError{id: 3})).Error("message")
+	logger.WithErr(nil).Error("message")
+	logger.WithErr((*testStackError)(nil)).Error("message")
+
+	test.Equals(t, 3, len(tw.LogLines))
+	test.Includes(t, ` error="get user: not found"`+
+		` error.chain.0.fields.id=3`+
+		` error.chain.0.message="not found"`+
+		` error.chain.0.type=*log_test.testFieldsError`+
+		` error.type=*fmt.wrapError`, tw.LogLines[0])
+	test.Includes(t, " error=null", tw.LogLines[1])
+	test.Includes(t, " error=null", tw.LogLines[2])
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Field is a single key and value, created with one of the typed
+// constructors and combined into Fields with NewFields.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func NewFields(fields ...Field) Fields {
+	f := make(Fields, len(fields))
+	for _, field := range fields {
+		f[field.Key] = field.Value
+	}
+
+	return f
+}
+
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Lazy adds a value that is only computed when a line with it is actually
+// written, so that expensive values cost nothing when the level is
+// disabled.
+func Lazy(key string, value func() interface{}) Field {
+	return Field{Key: key, Value: LazyValue(value)}
+}
+
+// LazyValue is a field value that is computed when the line is written.
+type LazyValue func() interface{}
+
+// LogValue makes slog resolve the value just as lazily.
+func (lv LazyValue) LogValue() slog.Value {
+	return slog.AnyValue(lv())
+}
+
+// resolveFields returns fields with all lazy values computed, and redacted
+// errors expanded. The original map is returned if there are none.
+func resolveFields(fields Fields) Fields {
+	resolved := fields
+	copied := false
+	for k, v := range fields {
+		var value interface{}
+		switch v := v.(type) {
+		case LazyValue:
+			value = v()
+		case errorValue:
+			if v.redact == nil {
+				continue
+			}
+			value = v.expand()
+		default:
+			continue
+		}
+		if !copied {
+			resolved = make(Fields, len(fields))
+			for ck, cv := range fields {
+				resolved[ck] = cv
+			}
+			copied = true
+		}
+		resolved[k] = value
+	}
+
+	return resolved
+}
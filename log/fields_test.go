@@ -0,0 +1,82 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestNewFields(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	logger.With(log.NewFields(
+		log.String("s", "value"),
+		log.Int("i", 3),
+		log.Duration("d", 1500*time.Millisecond),
+		log.Time("t", ts),
+		log.Any("a", []int{1, 2}),
+	)).Info("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	test.Includes(t, `"message":"message","a":[1,2],"d":"1.5s","i":3,"s":"value","t":"2024-01-02T03:04:05Z"}`, tw.LogLines[0])
+}
+
+func TestLazy(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw)
+	calls := 0
+	lazy := logger.With(log.NewFields(log.Lazy("dump", func() interface{} {
+		calls++
+		return "expensive"
+	})))
+
+	lazy.Debug("debug")
+	test.Equals(t, 0, calls)
+	test.Equals(t, 0, len(tw.LogLines))
+
+	lazy.Info("info")
+	test.Equals(t, 1, calls)
+	test.Includes(t, `"dump":"expensive"`, tw.LogLines...)
+
+	out := log.NewTestOut()
+	log.NewTestLogger(out).WithField("dump", log.LazyValue(func() interface{} { return 1 })).Info("info")
+	test.Equals(t, log.Fields{"dump": 1}, out.Lines[0].Fields)
+
+	buf := &bytes.Buffer{}
+	log.NewSlogLogger(slog.NewJSONHandler(buf, nil)).With(log.NewFields(log.Lazy("dump", func() interface{} {
+		return "slog"
+	}))).Info("info")
+	test.Includes(t, `"dump":"slog"`, buf.String())
+}
+
+func TestEnabled(t *testing.T) {
+	spec, err := log.ParseLevelSpec("info,db=debug")
+	test.OK(t, err)
+	level := log.NewAtomicLevel(log.LevelInfo)
+	level.SetSpec(spec)
+	logger := log.NewGoKitIOLogger(&testWriter{}, log.WithAtomicLevel(level))
+	named := logger.Named("db")
+
+	test.Equals(t, false, logger.Enabled(log.LevelDebug))
+	test.Equals(t, true, logger.Enabled(log.LevelInfo))
+	test.Equals(t, true, named.Enabled(log.LevelDebug))
+
+	tee := log.NewTee(
+		log.Sink{Logger: logger, Level: log.LevelError},
+		log.Sink{Logger: log.NewGoKitIOLogger(&testWriter{})},
+	)
+	test.Equals(t, false, tee.Enabled(log.LevelDebug))
+	test.Equals(t, true, tee.Enabled(log.LevelInfo))
+
+	slogger := log.NewSlogLogger(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelError}))
+	test.Equals(t, false, slogger.Enabled(log.LevelInfo))
+	test.Equals(t, true, slogger.Enabled(log.LevelError))
+	test.Equals(t, false, slog.New(log.NewSlogHandler(logger)).Enabled(context.Background(), slog.LevelDebug))
+}
@@ -2,81 +2,371 @@ package log
 
 import (
 	"io"
-
-	kitlog "github.com/go-kit/kit/log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
+// maxChainDepth limits the number of loggers a line has to visit to
+// collect its fields.
+const maxChainDepth = 8
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// encodedFields caches the encoded fields of a logger. It is shared by the
+// copies that WithGroup makes, which hold the same fields.
+type encodedFields struct {
+	once sync.Once
+	buf  []byte
+}
+
+// GoKitIOLogger writes each line with the time, level and message first,
+// followed by the fields in the order they were added. Fields that are
+// added in a single call are sorted by key. Fields added after WithGroup
+// get the group names as dotted prefix. Fields that would overwrite a key
+// the logger writes itself, like "message", are prefixed with "fields.".
+//
+// Derived loggers form a chain. Each holds only the fields it added, which
+// are encoded once, when the first line is written, so that neither deriving
+// a logger nor writing a line copies the fields of its parents. Values that
+// change after that line are not seen by later lines; use Lazy for those.
+// Lazy values and errors are encoded again for every line.
 type GoKitIOLogger struct {
-	fields Fields
-	level  LogLevel
-	logger kitlog.Logger
+	parent  *GoKitIOLogger
+	depth   int
+	fields  []field
+	encoded *encodedFields
+	dynamic bool
+	name    string
+	group   string
+	opts    *options
+	enc     encoder
+	out     io.Writer
 }
 
-func NewGoKitIOLogger(out io.Writer) Logger {
-	kl := kitlog.NewJSONLogger(out)
-	kl = kitlog.With(kl, "time", kitlog.DefaultTimestampUTC)
+func NewGoKitIOLogger(out io.Writer, opts ...Option) Logger {
+	o := newOptions(opts)
+
+	var enc encoder
+	switch o.format {
+	case FormatLogfmt:
+		enc = newLogfmtEncoder(o)
+	case FormatConsole:
+		color := useColor(out)
+		if o.color != nil {
+			color = *o.color
+		}
+		enc = newConsoleEncoder(color)
+	case FormatSyslog:
+		enc = newSyslogEncoder(o.syslog)
+	default:
+		enc = newJSONEncoder(o)
+	}
 
 	return &GoKitIOLogger{
-		fields: make(Fields),
-		level:  LevelInfo,
-		logger: kl,
+		opts: o,
+		enc:  enc,
+		out:  out,
 	}
 }
 
+// SetLogLevel changes the default level. On a named logger it only changes
+// the level of that name, which applies to its children as well.
 func (kl *GoKitIOLogger) SetLogLevel(loglevel LogLevel) {
-	kl.level = loglevel
+	kl.opts.level.SetNamedLevel(kl.name, loglevel)
+}
+
+// SetLevelSpec replaces the levels of this logger and of all loggers that
+// share its level. To control the levels through the Logger interface, pass
+// an AtomicLevel with WithAtomicLevel.
+func (kl *GoKitIOLogger) SetLevelSpec(spec LevelSpec) {
+	kl.opts.level.SetSpec(spec)
+}
+
+func (kl *GoKitIOLogger) Named(name string) Logger {
+	fullName := joinName(kl.name, name)
+	nl := kl.with([]field{{key: nameKey, value: fullName}})
+	nl.name = fullName
+
+	return nl
+}
+
+func (kl *GoKitIOLogger) WithGroup(name string) Logger {
+	if name == "" {
+		return kl
+	}
+
+	nl := *kl
+	nl.group = kl.group + name + "."
+
+	return &nl
 }
 
 func (kl *GoKitIOLogger) WithField(key string, value interface{}) Logger {
-	return kl.With(Fields{
-		key: value,
-	})
+	return kl.with([]field{{key: kl.fieldKey(key), value: value}})
 }
 
+// WithErr adds err as "error". It is written as an object with the
+// message, the type and the wrapped errors, see expandError. Formats other
+// than FormatJSON write the parts as separate dotted keys.
 func (kl *GoKitIOLogger) WithErr(err error) Logger {
-	return kl.With(Fields{
-		"error": err,
-	})
+	return kl.with([]field{{key: kl.fieldKey("error"), value: errorValue{err: err}}})
 }
 
 func (kl *GoKitIOLogger) With(fields Fields) Logger {
-	newFields := make(Fields)
-	for k, v := range kl.fields {
-		newFields[k] = v
+	if len(fields) == 0 {
+		return kl
 	}
+
+	added := make([]field, 0, len(fields))
 	for k, v := range fields {
-		newFields[k] = v
+		added = append(added, field{key: k, value: v})
 	}
-
-	return &GoKitIOLogger{
-		fields: newFields,
-		level:  kl.level,
-		logger: kl.logger,
+	slices.SortFunc(added, func(a, b field) int {
+		return strings.Compare(a.key, b.key)
+	})
+	for i := range added {
+		added[i].key = kl.fieldKey(added[i].key)
 	}
+
+	return kl.with(added)
 }
 
 func (kl *GoKitIOLogger) Debug(message string) {
-	if kl.level == LevelDebug {
-		kl.log("debug", message)
-	}
+	kl.log(LevelDebug, message)
 }
 
 func (kl *GoKitIOLogger) Info(message string) {
-	if kl.level != LevelError {
-		kl.log("info", message)
-	}
+	kl.log(LevelInfo, message)
 }
 
 func (kl *GoKitIOLogger) Error(message string) {
-	kl.log("error", message)
+	kl.log(LevelError, message)
+}
+
+// Enabled reports whether lines at level are written. Use it to skip
+// expensive preparations of a line that would be discarded anyway.
+func (kl *GoKitIOLogger) Enabled(level LogLevel) bool {
+	return kl.opts.level.Level(kl.name).allows(level)
+}
+
+// log builds the line in a pooled buffer and writes it with a single call.
+// The caller and the fields of the chain are encoded in the same buffer,
+// before the line itself.
+func (kl *GoKitIOLogger) log(level LogLevel, message string) {
+	if !kl.Enabled(level) {
+		return
+	}
+
+	bp := getBuffer()
+	buf := *bp
+	e := entry{
+		time:    time.Now(),
+		level:   level,
+		message: message,
+	}
+	if kl.opts.caller {
+		if frame, ok := caller(); ok {
+			start := len(buf)
+			buf = kl.enc.appendField(buf, callerKey, shortFile(frame.File, frame.Line))
+			if kl.opts.function {
+				buf = kl.enc.appendField(buf, functionKey, shortFunction(frame.Function))
+			}
+			e.extra = buf[start:]
+		}
+	}
+	if kl.parent == nil && !kl.dynamic {
+		e.fields = kl.encodedFields()
+	} else {
+		start := len(buf)
+		buf = kl.appendChain(buf)
+		e.fields = buf[start:]
+	}
+	start := len(buf)
+	buf = kl.enc.appendLine(buf, e)
+	kl.out.Write(buf[start:])
+
+	*bp = buf
+	putBuffer(bp)
+}
+
+// fieldKey adds the group prefix to key and moves keys that are reserved
+// out of the way.
+func (kl *GoKitIOLogger) fieldKey(key string) string {
+	key = kl.group + key
+	if kl.opts.reserved(key) {
+		key = reservedPrefix + key
+	}
+
+	return key
+}
+
+// with returns a logger with fields added to the chain. Replacing the
+// value of an existing key, or a chain that grows beyond maxChainDepth,
+// collapses the chain into a single logger that holds all fields.
+func (kl *GoKitIOLogger) with(fields []field) *GoKitIOLogger {
+	// the logger and its cache are allocated together
+	d := &struct {
+		logger  GoKitIOLogger
+		encoded encodedFields
+	}{logger: *kl}
+	nl := &d.logger
+	nl.parent, nl.depth = kl, kl.depth+1
+	if len(kl.fields) == 0 {
+		// skip loggers that added nothing, like the root
+		nl.parent, nl.depth = kl.parent, kl.depth
+	}
+	nl.fields = fields
+
+	replaced := false
+	for i, f := range fields {
+		if kl.has(f.key) || slices.ContainsFunc(fields[:i], func(prev field) bool { return prev.key == f.key }) {
+			replaced = true
+			break
+		}
+	}
+	if replaced || nl.depth > maxChainDepth {
+		nl.fields = kl.collect(make([]field, 0, kl.count()+len(fields)))
+		for _, f := range fields {
+			nl.fields = setField(nl.fields, f.key, f.value)
+		}
+		nl.parent, nl.depth = nil, 0
+	}
+
+	nl.dynamic = hasDynamic(nl.fields)
+	nl.encoded = nil
+	if !nl.dynamic {
+		nl.encoded = &d.encoded
+	}
+
+	return nl
+}
+
+// encodedFields returns the fields of the logger, encoded on the first
+// call. Deriving loggers that never write a line costs no encoding.
+func (kl *GoKitIOLogger) encodedFields() []byte {
+	if kl.encoded == nil {
+		// the root, which has no fields
+		return nil
+	}
+	kl.encoded.once.Do(func() {
+		bp := getBuffer()
+		*bp = kl.appendFields(*bp, kl.fields)
+		kl.encoded.buf = slices.Clone(*bp)
+		putBuffer(bp)
+	})
+
+	return kl.encoded.buf
+}
+
+// has reports whether key is present anywhere in the chain.
+func (kl *GoKitIOLogger) has(key string) bool {
+	for l := kl; l != nil; l = l.parent {
+		for _, f := range l.fields {
+			if f.key == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (kl *GoKitIOLogger) count() int {
+	n := 0
+	for l := kl; l != nil; l = l.parent {
+		n += len(l.fields)
+	}
+
+	return n
+}
+
+// collect appends the fields of the chain, oldest first.
+func (kl *GoKitIOLogger) collect(fields []field) []field {
+	if kl.parent != nil {
+		fields = kl.parent.collect(fields)
+	}
+
+	return append(fields, kl.fields...)
+}
+
+// appendChain appends the encoded fields of the chain, oldest first.
+func (kl *GoKitIOLogger) appendChain(buf []byte) []byte {
+	if kl.parent != nil {
+		buf = kl.parent.appendChain(buf)
+	}
+	if kl.dynamic {
+		return kl.appendFields(buf, kl.fields)
+	}
+
+	return append(buf, kl.encodedFields()...)
+}
+
+func (kl *GoKitIOLogger) appendFields(buf []byte, fields []field) []byte {
+	for _, f := range fields {
+		buf = kl.appendField(buf, f.key, f.value)
+	}
+
+	return buf
+}
+
+func (kl *GoKitIOLogger) appendField(buf []byte, key string, value interface{}) []byte {
+	if lv, ok := value.(LazyValue); ok {
+		value = lv()
+	}
+	ev, isErr := value.(errorValue)
+	if isErr {
+		m := ev.expand()
+		if m == nil {
+			return kl.enc.appendField(buf, key, nil)
+		}
+		value = m
+	}
+	if kl.opts.format == FormatJSON || kl.opts.format == "" {
+		return kl.enc.appendField(buf, key, value)
+	}
+	if !isErr {
+		return flatten(buf, kl.enc, key, value)
+	}
+
+	// keep the message under the key itself, so that it reads like before
+	m := value.(map[string]interface{})
+	buf = kl.enc.appendField(buf, key, m["message"])
+	for _, k := range sortedKeys(m) {
+		if k != "message" {
+			buf = flatten(buf, kl.enc, key+"."+k, m[k])
+		}
+	}
+
+	return buf
+}
+
+// setField replaces the value of key if it is present and appends it
+// otherwise.
+func setField(fields []field, key string, value interface{}) []field {
+	for i := range fields {
+		if fields[i].key == key {
+			fields[i].value = value
+			return fields
+		}
+	}
+
+	return append(fields, field{key: key, value: value})
 }
 
-func (kl *GoKitIOLogger) log(level, message string) {
-	kv := make([]interface{}, 0)
-	for k, v := range kl.fields {
-		kv = append(kv, k, v)
+// hasDynamic reports whether any of the fields has to be encoded for
+// every line.
+func hasDynamic(fields []field) bool {
+	for _, f := range fields {
+		switch f.value.(type) {
+		case LazyValue, errorValue:
+			return true
+		}
 	}
-	kv = append(kv, "level", level, "message", message)
 
-	kl.logger.Log(kv...)
+	return false
 }
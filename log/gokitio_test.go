@@ -1,9 +1,14 @@
 package log_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"go-mod.ewintr.nl/go-kit/log"
 	"go-mod.ewintr.nl/go-kit/test"
@@ -227,3 +232,282 @@ func TestGoKitIOLoggerWith(t *testing.T) {
 		})
 	}
 }
+
+func TestGoKitIOLoggerNamed(t *testing.T) {
+	tw := &testWriter{}
+	spec, err := log.ParseLevelSpec("info,db=debug,http=error")
+	test.OK(t, err)
+	level := log.NewAtomicLevel(log.LevelInfo)
+	level.SetSpec(spec)
+	logger := log.NewGoKitIOLogger(tw, log.WithAtomicLevel(level))
+
+	db := logger.Named("db")
+	http := logger.Named("http")
+	client := http.Named("client")
+
+	for _, tc := range []struct {
+		name   string
+		logger log.Logger
+		exp    int
+	}{
+		{
+			name:   "root",
+			logger: logger,
+			exp:    2,
+		},
+		{
+			name:   "db",
+			logger: db,
+			exp:    3,
+		},
+		{
+			name:   "http",
+			logger: http,
+			exp:    1,
+		},
+		{
+			name:   "nested",
+			logger: client.WithField("key", "value"),
+			exp:    1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw.Flush()
+			tc.logger.Debug("debug")
+			tc.logger.Info("info")
+			tc.logger.Error("error")
+
+			test.Equals(t, tc.exp, len(tw.LogLines))
+		})
+	}
+
+	tw.Flush()
+	client.Error("message")
+	test.Includes(t, `"logger":"http.client"`, tw.LogLines...)
+}
+
+func TestGoKitIOLoggerFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		format log.Format
+		fields log.Fields
+		exp    []string
+	}{
+		{
+			name:   "json",
+			format: log.FormatJSON,
+			fields: log.Fields{"key": "some value"},
+			exp: []string{
+				`"level":"info"`,
+				`"message":"message"`,
+				`"key":"some value"`,
+			},
+		},
+		{
+			name:   "logfmt",
+			format: log.FormatLogfmt,
+			fields: log.Fields{
+				"key":     "value",
+				"spaces":  "some value",
+				"newline": "first\nsecond",
+				"number":  3,
+			},
+			exp: []string{
+				" level=info ",
+				" message=message",
+				" key=value",
+				` spaces="some value"`,
+				` newline="first\nsecond"`,
+				" number=3",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWriter{}
+			logger := log.NewGoKitIOLogger(tw, log.WithFormat(tc.format))
+			logger.With(tc.fields).Info("message")
+
+			test.Equals(t, 1, len(tw.LogLines))
+			for _, exp := range tc.exp {
+				test.Includes(t, exp, tw.LogLines[0])
+			}
+		})
+	}
+}
+
+func TestGoKitIOLoggerFieldOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		format log.Format
+		exp    *regexp.Regexp
+	}{
+		{
+			name:   "json",
+			format: log.FormatJSON,
+			exp:    regexp.MustCompile(`^{"time":"[^"]+","level":"info","message":"message","z":1,"a":2,"c":3,"b":4}\n$`),
+		},
+		{
+			name:   "logfmt",
+			format: log.FormatLogfmt,
+			exp:    regexp.MustCompile(`^time=\S+ level=info message=message z=1 a=2 c=3 b=4\n$`),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWriter{}
+			logger := log.NewGoKitIOLogger(tw, log.WithFormat(tc.format)).
+				WithField("z", 1).
+				With(log.Fields{"c": 3, "a": 2}).
+				WithField("b", 4).
+				WithField("a", 2)
+
+			for i := 0; i < 10; i++ {
+				tw.Flush()
+				logger.Info("message")
+
+				test.Equals(t, 1, len(tw.LogLines))
+				test.Assert(t, tc.exp.MatchString(tw.LogLines[0]), "unexpected line", tw.LogLines[0])
+			}
+		})
+	}
+}
+
+func TestGoKitIOLoggerKeys(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw,
+		log.WithKeys(log.Keys{Time: "ts", Message: "msg"}),
+		log.WithTimeFormat(log.TimeFormatUnixMilli),
+	)
+
+	before := time.Now().UnixMilli()
+	logger.Info("message")
+	after := time.Now().UnixMilli()
+
+	test.Equals(t, 1, len(tw.LogLines))
+	var line struct {
+		TS    int64  `json:"ts"`
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &line))
+	test.Assert(t, line.TS >= before && line.TS <= after, "unexpected timestamp", line.TS)
+	test.Equals(t, "info", line.Level)
+	test.Equals(t, "message", line.Msg)
+}
+
+func TestGoKitIOLoggerWithGroup(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw).
+		WithField("service", "api").
+		WithGroup("http").
+		WithField("method", "GET").
+		WithGroup("req").
+		WithErr(errors.New("failed")).
+		Named("server")
+
+	logger.Info("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	test.Includes(t, `"message":"message","service":"api","http.method":"GET","http.req.error":{"message":"failed","type":"*errors.errorString"},"logger":"server"}`, tw.LogLines[0])
+}
+
+func TestGoKitIOLoggerReservedKeys(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw, log.WithKeys(log.Keys{Message: "msg"})).With(log.Fields{
+		"time":    "field time",
+		"level":   "field level",
+		"msg":     "field message",
+		"logger":  "field logger",
+		"message": "not reserved",
+	})
+
+	logger.Error("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	line := map[string]interface{}{}
+	test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &line))
+	test.Equals(t, "error", line["level"])
+	test.Equals(t, "message", line["msg"])
+	test.IncludesMap(t, map[string]interface{}{
+		"fields.time":   "field time",
+		"fields.level":  "field level",
+		"fields.msg":    "field message",
+		"fields.logger": "field logger",
+		"message":       "not reserved",
+	}, line)
+}
+
+func TestGoKitIOLoggerDerived(t *testing.T) {
+	tw := &testWriter{}
+	root := log.NewGoKitIOLogger(tw)
+	logger := root
+	exp := `"message":"message"`
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("k%02d", i)
+		logger = logger.WithField(key, i)
+		exp += fmt.Sprintf(`,"%s":%d`, key, i)
+	}
+	parent := logger
+	logger = logger.WithField("k03", "replaced").WithField("lazy", log.LazyValue(func() interface{} { return "computed" }))
+
+	logger.Info("message")
+	parent.Info("message")
+	root.Info("message")
+
+	test.Equals(t, 3, len(tw.LogLines))
+	test.Includes(t, strings.Replace(exp, `"k03":3`, `"k03":"replaced"`, 1)+`,"lazy":"computed"}`, tw.LogLines[0])
+	test.Includes(t, exp+"}", tw.LogLines[1])
+	test.Includes(t, `"message":"message"}`, tw.LogLines[2])
+}
+
+func TestGoKitIOLoggerEncodedOnFirstLine(t *testing.T) {
+	tw := &testWriter{}
+	attempts := []int{1}
+	logger := log.NewGoKitIOLogger(tw).WithField("attempts", attempts)
+	grouped := logger.WithGroup("http")
+
+	attempts[0] = 2
+	logger.Info("first")
+	attempts[0] = 3
+	grouped.Info("second")
+
+	test.Equals(t, 2, len(tw.LogLines))
+	test.Includes(t, `"attempts":[2]`, tw.LogLines[0])
+	test.Includes(t, `"attempts":[2]`, tw.LogLines[1])
+}
+
+func TestGoKitIOLoggerJSONValues(t *testing.T) {
+	tw := &testWriter{}
+	fields := log.Fields{
+		"string":  "a \"quoted\"\n<html> & \u2028 \x01 value",
+		"invalid": "a\xffb",
+		"int":     -3,
+		"uint":    uint8(7),
+		"float":   0.000001,
+		"big":     1e21,
+		"nan":     math.NaN(),
+		"bool":    true,
+		"nil":     nil,
+		"date":    time.Date(2024, 2, 3, 4, 5, 6, 7, time.UTC),
+		"slice":   []interface{}{1, "two"},
+		"struct":  struct{ A int }{A: 1},
+		"map":     map[string]interface{}{"b": 2, "a": 1},
+	}
+	log.NewGoKitIOLogger(tw).With(fields).Info("message")
+
+	test.Equals(t, 1, len(tw.LogLines))
+	line := map[string]interface{}{}
+	test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &line))
+	fields["invalid"] = "a\ufffdb"
+	fields["int"] = float64(-3)
+	fields["uint"] = float64(7)
+	fields["nan"] = "NaN"
+	fields["date"] = "2024-02-03T04:05:06.000000007Z"
+	fields["slice"] = []interface{}{float64(1), "two"}
+	fields["struct"] = map[string]interface{}{"A": float64(1)}
+	fields["map"] = map[string]interface{}{"a": float64(1), "b": float64(2)}
+	for k, v := range fields {
+		test.Equals(t, v, line[k])
+	}
+	test.Includes(t, `"map":{"a":1,"b":2}`, tw.LogLines[0])
+	test.Includes(t, `<html> & \u2028 \u0001`, tw.LogLines[0])
+}
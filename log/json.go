@@ -0,0 +1,70 @@
+package log
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// jsonEncoder writes a JSON object per line. Unlike the go-kit JSON logger
+// it keeps the keys in the order they are passed.
+type jsonEncoder struct {
+	keys       Keys
+	timeFormat string
+}
+
+func newJSONEncoder(o *options) *jsonEncoder {
+	return &jsonEncoder{
+		keys:       o.keys,
+		timeFormat: o.timeFormat,
+	}
+}
+
+func (je *jsonEncoder) appendField(buf []byte, key string, value interface{}) []byte {
+	buf = append(buf, ',')
+	buf = appendQuoted(buf, key)
+	buf = append(buf, ':')
+
+	return appendJSONValue(buf, value)
+}
+
+func (je *jsonEncoder) appendLine(buf []byte, e entry) []byte {
+	buf = append(buf, '{')
+	buf = appendQuoted(buf, je.keys.Time)
+	buf = append(buf, ':')
+	buf = je.appendTime(buf, e.time)
+	buf = je.appendField(buf, je.keys.Level, string(e.level))
+	buf = je.appendField(buf, je.keys.Message, e.message)
+	buf = append(buf, e.extra...)
+	buf = append(buf, e.fields...)
+
+	return append(buf, '}', '\n')
+}
+
+func (je *jsonEncoder) appendTime(buf []byte, t time.Time) []byte {
+	if je.timeFormat == TimeFormatUnixMilli {
+		return strconv.AppendInt(buf, t.UnixMilli(), 10)
+	}
+
+	buf = append(buf, '"')
+	start := len(buf)
+	buf = t.UTC().AppendFormat(buf, je.timeFormat)
+	if !plainASCII(buf[start:]) {
+		s := string(buf[start:])
+		return appendQuoted(buf[:start-1], s)
+	}
+
+	return append(buf, '"')
+}
+
+// plainASCII reports whether b only has printable ASCII characters that
+// need no quoting or escaping in any of the formats.
+func plainASCII(b []byte) bool {
+	for _, c := range b {
+		if c <= ' ' || c == '"' || c == '\\' || c == '=' || c >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return len(b) > 0
+}
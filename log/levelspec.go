@@ -0,0 +1,101 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidLogLevel  = errors.New("invalid log level")
+	ErrInvalidLevelSpec = errors.New("invalid level spec")
+)
+
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch ll := LogLevel(strings.ToLower(strings.TrimSpace(level))); ll {
+	case LevelDebug, LevelInfo, LevelError:
+		return ll, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidLogLevel, level)
+	}
+}
+
+// LevelSpec holds a default level and levels for named loggers. A name
+// matches its own entry or, failing that, the entry of its closest parent,
+// so "db" also applies to "db.pool".
+type LevelSpec struct {
+	Default LogLevel
+	Names   map[string]LogLevel
+}
+
+// ParseLevelSpec parses a comma separated list of levels, like
+// "info,db=debug,http=error". An entry without a name sets the default.
+func ParseLevelSpec(spec string) (LevelSpec, error) {
+	ls := LevelSpec{
+		Default: LevelInfo,
+		Names:   make(map[string]LogLevel),
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, level, named := strings.Cut(entry, "=")
+		if !named {
+			level, name = name, ""
+		}
+		ll, err := ParseLogLevel(level)
+		if err != nil {
+			return LevelSpec{}, fmt.Errorf("%w: %v", ErrInvalidLevelSpec, err)
+		}
+
+		name = strings.TrimSpace(name)
+		switch {
+		case !named:
+			ls.Default = ll
+		case name == "":
+			return LevelSpec{}, fmt.Errorf("%w: missing name in %q", ErrInvalidLevelSpec, entry)
+		default:
+			ls.Names[name] = ll
+		}
+	}
+
+	return ls, nil
+}
+
+// Level returns the level for the logger with the given name.
+func (ls LevelSpec) Level(name string) LogLevel {
+	for name != "" {
+		if ll, ok := ls.Names[name]; ok {
+			return ll
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	if ls.Default == "" {
+		return LevelInfo
+	}
+
+	return ls.Default
+}
+
+func (ls LevelSpec) String() string {
+	entries := []string{string(ls.Level(""))}
+	for _, name := range sortedKeys(ls.Names) {
+		entries = append(entries, fmt.Sprintf("%s=%s", name, ls.Names[name]))
+	}
+
+	return strings.Join(entries, ",")
+}
+
+func joinName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
@@ -0,0 +1,82 @@
+package log_test
+
+import (
+	"errors"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		spec   string
+		expErr error
+		exp    log.LevelSpec
+	}{
+		{
+			name: "empty",
+			exp: log.LevelSpec{
+				Default: log.LevelInfo,
+				Names:   map[string]log.LogLevel{},
+			},
+		},
+		{
+			name: "default only",
+			spec: "error",
+			exp: log.LevelSpec{
+				Default: log.LevelError,
+				Names:   map[string]log.LogLevel{},
+			},
+		},
+		{
+			name: "named",
+			spec: "info, db=debug,http.client=ERROR",
+			exp: log.LevelSpec{
+				Default: log.LevelInfo,
+				Names: map[string]log.LogLevel{
+					"db":          log.LevelDebug,
+					"http.client": log.LevelError,
+				},
+			},
+		},
+		{
+			name:   "invalid level",
+			spec:   "info,db=verbose",
+			expErr: log.ErrInvalidLevelSpec,
+		},
+		{
+			name:   "missing name",
+			spec:   "=debug",
+			expErr: log.ErrInvalidLevelSpec,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			act, err := log.ParseLevelSpec(tc.spec)
+			test.Assert(t, errors.Is(err, tc.expErr), "unexpected error", err)
+			if tc.expErr != nil {
+				return
+			}
+			test.Equals(t, tc.exp, act)
+		})
+	}
+}
+
+func TestLevelSpecLevel(t *testing.T) {
+	spec, err := log.ParseLevelSpec("info,db=debug,db.pool=error")
+	test.OK(t, err)
+
+	for name, exp := range map[string]log.LogLevel{
+		"":             log.LevelInfo,
+		"http":         log.LevelInfo,
+		"db":           log.LevelDebug,
+		"db.query":     log.LevelDebug,
+		"db.pool":      log.LevelError,
+		"db.pool.conn": log.LevelError,
+		"dbx":          log.LevelInfo,
+	} {
+		test.Equals(t, exp, spec.Level(name))
+	}
+	test.Equals(t, "info,db=debug,db.pool=error", spec.String())
+}
@@ -1,6 +1,9 @@
 package log
 
-import "io"
+import (
+	"io"
+	"sort"
+)
 
 const (
 	LevelDebug = LogLevel("debug")
@@ -10,18 +13,60 @@ const (
 
 type LogLevel string
 
+// allows reports whether a message at the given level passes a logger set
+// to this level.
+func (ll LogLevel) allows(level LogLevel) bool {
+	return levelRank(level) >= levelRank(ll)
+}
+
+func levelRank(level LogLevel) int {
+	switch level {
+	case LevelDebug:
+		return 0
+	case LevelError:
+		return 2
+	default:
+		return 1
+	}
+}
+
 type Fields map[string]interface{}
 
 type Logger interface {
 	SetLogLevel(loglevel LogLevel)
+	Named(name string) Logger
+	WithGroup(name string) Logger
 	WithField(key string, value interface{}) Logger
 	WithErr(err error) Logger
 	With(fields Fields) Logger
 	Debug(message string)
 	Info(message string)
 	Error(message string)
+	Enabled(level LogLevel) bool
+}
+
+func New(out io.Writer, opts ...Option) Logger {
+	return NewGoKitIOLogger(out, opts...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
 }
 
-func New(out io.Writer) Logger {
-	return NewGoKitIOLogger(out)
+// logAt calls the method of logger that matches level.
+func logAt(logger Logger, level LogLevel, message string) {
+	switch level {
+	case LevelDebug:
+		logger.Debug(message)
+	case LevelError:
+		logger.Error(message)
+	default:
+		logger.Info(message)
+	}
 }
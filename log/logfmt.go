@@ -0,0 +1,156 @@
+package log
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// logfmtEncoder writes key=value pairs separated by spaces, with the same
+// rules for keys and values as github.com/go-logfmt/logfmt.
+type logfmtEncoder struct {
+	keys       Keys
+	timeFormat string
+}
+
+func newLogfmtEncoder(o *options) *logfmtEncoder {
+	return &logfmtEncoder{
+		keys:       o.keys,
+		timeFormat: o.timeFormat,
+	}
+}
+
+// appendField skips keys that have no valid characters at all.
+func (le *logfmtEncoder) appendField(buf []byte, key string, value interface{}) []byte {
+	start := len(buf)
+	buf = append(buf, ' ')
+	if buf = appendLogfmtKey(buf, key); len(buf) == start+1 {
+		return buf[:start]
+	}
+	buf = append(buf, '=')
+
+	return appendLogfmtValue(buf, value)
+}
+
+func (le *logfmtEncoder) appendLine(buf []byte, e entry) []byte {
+	buf = appendLogfmtKey(buf, le.keys.Time)
+	buf = append(buf, '=')
+	buf = le.appendTime(buf, e.time)
+	buf = le.appendField(buf, le.keys.Level, string(e.level))
+	buf = le.appendField(buf, le.keys.Message, e.message)
+	buf = append(buf, e.extra...)
+	buf = append(buf, e.fields...)
+
+	return append(buf, '\n')
+}
+
+func (le *logfmtEncoder) appendTime(buf []byte, t time.Time) []byte {
+	if le.timeFormat == TimeFormatUnixMilli {
+		return strconv.AppendInt(buf, t.UnixMilli(), 10)
+	}
+
+	start := len(buf)
+	buf = t.UTC().AppendFormat(buf, le.timeFormat)
+	if !plainASCII(buf[start:]) {
+		s := string(buf[start:])
+		return appendLogfmtString(buf[:start], s, true)
+	}
+
+	return buf
+}
+
+func appendLogfmtKey(buf []byte, key string) []byte {
+	for _, r := range key {
+		if !logfmtQuoteRune(r) {
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+
+	return buf
+}
+
+func appendLogfmtValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendLogfmtString(buf, v, true)
+	case []byte:
+		return appendLogfmtString(buf, string(v), false)
+	case float32:
+		return strconv.AppendFloat(buf, float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case encoding.TextMarshaler:
+		text, err := safeMarshalText(v)
+		switch {
+		case err != nil:
+			return appendLogfmtString(buf, fmt.Sprintf("error marshaling value of type %T: %v", value, err), false)
+		case text == nil:
+			return append(buf, "null"...)
+		default:
+			return appendLogfmtString(buf, string(text), false)
+		}
+	case error:
+		return appendLogfmtText(buf, value, v.Error)
+	case fmt.Stringer:
+		return appendLogfmtText(buf, value, v.String)
+	}
+	if b, ok := appendNumber(buf, value); ok {
+		return b
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Func, reflect.Map, reflect.Slice, reflect.Struct:
+		return appendLogfmtString(buf, "unsupported value type", false)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return append(buf, "null"...)
+		}
+		return appendLogfmtValue(buf, rv.Elem().Interface())
+	default:
+		return appendLogfmtString(buf, fmt.Sprint(value), false)
+	}
+}
+
+func appendLogfmtText(buf []byte, value interface{}, f func() string) []byte {
+	s, ok := safeText(value, f)
+	if !ok {
+		return append(buf, "null"...)
+	}
+
+	return appendLogfmtString(buf, s, true)
+}
+
+// appendLogfmtString quotes s when needed. With checkNull the string
+// "null" is quoted too, so that it cannot be mistaken for a nil value.
+func appendLogfmtString(buf []byte, s string, checkNull bool) []byte {
+	if (checkNull && s == "null") || strings.IndexFunc(s, logfmtQuoteRune) != -1 {
+		return appendQuoted(buf, s)
+	}
+
+	return append(buf, s...)
+}
+
+func logfmtQuoteRune(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError
+}
+
+func safeMarshalText(tm encoding.TextMarshaler) (text []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(tm); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				text, err = nil, nil
+				return
+			}
+			text, err = nil, fmt.Errorf("PANIC in value: %v", r)
+		}
+	}()
+
+	return tm.MarshalText()
+}
@@ -0,0 +1,140 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+type requestIDContextKey struct{}
+
+// HTTPMiddleware logs every request that is handled by next. It takes the
+// request ID from the X-Request-ID header, or generates one, and sets it on
+// the response. Handlers can get a logger with the ID from the request
+// context with FromContext. The trace of an incoming traceparent header is
+// continued in a new span, or a new trace is started, and the IDs are added
+// to the log lines. Requests that end with a 5xx status are logged as error,
+// others as info. If next panics, the request is logged as an error with
+// status 500 and the panic continues.
+func HTTPMiddleware(logger Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		reqLogger := logger.WithField(requestIDKey, id)
+		sc := spanFromRequest(r)
+		ctx := context.WithValue(r.Context(), requestIDContextKey{}, id)
+		ctx = ContextWithSpan(ctx, sc)
+		ctx = NewContext(ctx, reqLogger)
+
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			// a panic is logged and passed on to the server
+			recovered := recover()
+			if recovered != nil {
+				rw.status = http.StatusInternalServerError
+			}
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+
+			accessLogger := reqLogger.With(traceFields(sc)).With(Fields{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      rw.status,
+				"bytes":       rw.bytes,
+				"duration":    time.Since(start),
+				"remote_addr": r.RemoteAddr,
+			})
+			if recovered != nil {
+				accessLogger.WithField("panic", fmt.Sprint(recovered)).Error("request handled")
+				panic(recovered)
+			}
+			if rw.status >= http.StatusInternalServerError {
+				accessLogger.Error("request handled")
+				return
+			}
+			accessLogger.Info("request handled")
+		}()
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the request ID that HTTPMiddleware stored in
+// ctx, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += n
+
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, as for websockets. The
+// request is then logged with status 101 if no status was written.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: hijack", http.ErrNotSupported)
+	}
+	conn, buf, err := h.Hijack()
+	if err == nil && rw.status == 0 {
+		rw.status = http.StatusSwitchingProtocols
+	}
+
+	return conn, buf, err
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,165 @@
+package log_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	tw := &testWriter{}
+	handler := log.HTTPMiddleware(log.NewGoKitIOLogger(tw), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.FromContext(r.Context()).Info("handling")
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte(log.RequestIDFromContext(r.Context())))
+		}
+	}))
+
+	for _, tc := range []struct {
+		name      string
+		path      string
+		requestID string
+		expStatus int
+		expLevel  string
+	}{
+		{
+			name:      "ok",
+			path:      "/",
+			expStatus: http.StatusOK,
+			expLevel:  "info",
+		},
+		{
+			name:      "propagate id",
+			path:      "/",
+			requestID: "abc",
+			expStatus: http.StatusOK,
+			expLevel:  "info",
+		},
+		{
+			name:      "client error",
+			path:      "/missing",
+			expStatus: http.StatusNotFound,
+			expLevel:  "info",
+		},
+		{
+			name:      "server error",
+			path:      "/fail",
+			expStatus: http.StatusBadGateway,
+			expLevel:  "error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw.Flush()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.requestID != "" {
+				req.Header.Set(log.RequestIDHeader, tc.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			test.Equals(t, tc.expStatus, rec.Code)
+			id := rec.Header().Get(log.RequestIDHeader)
+			test.NotZero(t, id)
+			if tc.requestID != "" {
+				test.Equals(t, tc.requestID, id)
+			}
+
+			test.Equals(t, 2, len(tw.LogLines))
+			test.Includes(t, `"message":"handling","request_id":"`+id+`"`, tw.LogLines[0])
+			access := map[string]interface{}{}
+			test.OK(t, json.Unmarshal([]byte(tw.LogLines[1]), &access))
+			test.IncludesMap(t, map[string]interface{}{
+				"level":       tc.expLevel,
+				"message":     "request handled",
+				"request_id":  id,
+				"method":      http.MethodGet,
+				"path":        tc.path,
+				"status":      float64(tc.expStatus),
+				"bytes":       float64(rec.Body.Len()),
+				"remote_addr": req.RemoteAddr,
+			}, access)
+			test.Assert(t, access["duration"] != nil, "expected duration")
+		})
+	}
+}
+
+func TestHTTPMiddlewarePanic(t *testing.T) {
+	tw := &testWriter{}
+	handler := log.HTTPMiddleware(log.NewGoKitIOLogger(tw), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+
+	test.Equals(t, "boom", recovered)
+	test.Equals(t, 1, len(tw.LogLines))
+	access := map[string]interface{}{}
+	test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &access))
+	test.IncludesMap(t, map[string]interface{}{
+		"level":   "error",
+		"message": "request handled",
+		"path":    "/panic",
+		"status":  float64(http.StatusInternalServerError),
+		"panic":   "boom",
+	}, access)
+}
+
+func TestHTTPMiddlewareHijack(t *testing.T) {
+	sw := &syncWriter{}
+	srv := httptest.NewServer(log.HTTPMiddleware(log.New(sw), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(buf, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\nhello")
+		buf.Flush()
+	})))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	test.OK(t, err)
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, nil)
+	test.OK(t, err)
+	test.Equals(t, http.StatusSwitchingProtocols, res.StatusCode)
+	body, err := io.ReadAll(r)
+	test.OK(t, err)
+	test.Equals(t, "hello", string(body))
+
+	// the access line is written after the handler returns
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(sw.String(), "request handled") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	access := map[string]interface{}{}
+	test.OK(t, json.Unmarshal([]byte(sw.String()), &access))
+	test.IncludesMap(t, map[string]interface{}{
+		"path":   "/ws",
+		"status": float64(http.StatusSwitchingProtocols),
+	}, access)
+}
@@ -0,0 +1,132 @@
+package log
+
+import "time"
+
+const (
+	FormatJSON    = Format("json")
+	FormatLogfmt  = Format("logfmt")
+	FormatConsole = Format("console")
+	FormatSyslog  = Format("syslog")
+
+	TimeFormatRFC3339Nano = time.RFC3339Nano
+	TimeFormatUnixMilli   = "unixmilli"
+)
+
+type Format string
+
+const (
+	nameKey        = "logger"
+	reservedPrefix = "fields."
+)
+
+// Keys holds the names under which the time, level and message of a line
+// are written. Empty names fall back to "time", "level" and "message".
+type Keys struct {
+	Time    string
+	Level   string
+	Message string
+}
+
+type options struct {
+	level      *AtomicLevel
+	format     Format
+	color      *bool
+	keys       Keys
+	timeFormat string
+	caller     bool
+	function   bool
+	syslog     SyslogConfig
+}
+
+type Option func(*options)
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.level == nil {
+		o.level = NewAtomicLevel(LevelInfo)
+	}
+	if o.keys.Time == "" {
+		o.keys.Time = "time"
+	}
+	if o.keys.Level == "" {
+		o.keys.Level = "level"
+	}
+	if o.keys.Message == "" {
+		o.keys.Message = "message"
+	}
+	if o.timeFormat == "" {
+		o.timeFormat = TimeFormatRFC3339Nano
+	}
+
+	return o
+}
+
+// reserved reports whether key is written by the logger itself and cannot
+// be used for a field.
+func (o *options) reserved(key string) bool {
+	switch key {
+	case o.keys.Time, o.keys.Level, o.keys.Message, nameKey:
+		return true
+	case callerKey:
+		return o.caller
+	case functionKey:
+		return o.function
+	default:
+		return false
+	}
+}
+
+// WithAtomicLevel makes the logger use the given level instead of creating
+// its own, so that it can be shared or controlled over HTTP.
+func WithAtomicLevel(level *AtomicLevel) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithCaller adds the file and line that called the logger as "caller",
+// and with function set also the name of the calling function as
+// "function". Functions in this package and functions that called Helper
+// are skipped.
+func WithCaller(function bool) Option {
+	return func(o *options) {
+		o.caller = true
+		o.function = function
+	}
+}
+
+// WithColor forces colors in FormatConsole on or off. By default they are
+// used when the output is a terminal and NO_COLOR is not set.
+func WithColor(enabled bool) Option {
+	return func(o *options) {
+		o.color = &enabled
+	}
+}
+
+// WithFormat sets the output format. The default is FormatJSON.
+// FormatSyslog writes RFC 5424 messages, one per line, and can be combined
+// with a SyslogWriter to send them to a syslog server.
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+func WithKeys(keys Keys) Option {
+	return func(o *options) {
+		o.keys = keys
+	}
+}
+
+// WithTimeFormat sets the format of the time in FormatJSON and
+// FormatLogfmt. It is either a layout for time.Format, or
+// TimeFormatUnixMilli for milliseconds since the Unix epoch. The default is
+// TimeFormatRFC3339Nano in UTC.
+func WithTimeFormat(format string) Option {
+	return func(o *options) {
+		o.timeFormat = format
+	}
+}
@@ -0,0 +1,248 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const DefaultMask = "[REDACTED]"
+
+// Redactor can be implemented by values that know how to hide their own
+// sensitive parts. The logger uses the result of Redact instead of the
+// value itself.
+type Redactor interface {
+	Redact() interface{}
+}
+
+// RedactRules determine which values a RedactingLogger masks. Values of
+// fields with a key that matches one of Keys are replaced completely. In
+// strings, errors and fmt.Stringers, and in messages, the parts that match
+// one of Values are replaced. With CardNumbers set, runs of 13 to 19
+// digits, optionally separated by spaces or dashes, are replaced as well if
+// they pass the Luhn check, so that most timestamps and IDs are left alone.
+// Mask defaults to DefaultMask.
+type RedactRules struct {
+	Keys        []*regexp.Regexp
+	Values      []*regexp.Regexp
+	CardNumbers bool
+	Mask        string
+}
+
+var cardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+// DefaultRedactRules masks passwords, tokens, secrets, authorization
+// headers, credit card numbers and bearer tokens.
+var DefaultRedactRules = RedactRules{
+	Keys: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)passw(or)?d|token|secret|authorization|api[-_]?key`),
+	},
+	Values: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`),
+	},
+	CardNumbers: true,
+}
+
+// RedactingLogger is a Logger that masks sensitive values before they are
+// passed to the wrapped logger.
+type RedactingLogger struct {
+	logger Logger
+	rules  RedactRules
+}
+
+func NewRedactingLogger(logger Logger, rules RedactRules) Logger {
+	if rules.Mask == "" {
+		rules.Mask = DefaultMask
+	}
+
+	return &RedactingLogger{
+		logger: logger,
+		rules:  rules,
+	}
+}
+
+func (rl *RedactingLogger) SetLogLevel(loglevel LogLevel) {
+	rl.logger.SetLogLevel(loglevel)
+}
+
+func (rl *RedactingLogger) Named(name string) Logger {
+	return rl.wrap(rl.logger.Named(name))
+}
+
+func (rl *RedactingLogger) WithGroup(name string) Logger {
+	return rl.wrap(rl.logger.WithGroup(name))
+}
+
+func (rl *RedactingLogger) WithField(key string, value interface{}) Logger {
+	return rl.With(Fields{
+		key: value,
+	})
+}
+
+// WithErr adds err as "error". A Redactor is replaced by the result of
+// Redact. If err, or an error it wraps, carries fields or has a message
+// that matches the rules, it is passed on as the expanded error with the
+// fields and messages redacted.
+func (rl *RedactingLogger) WithErr(err error) Logger {
+	if _, ok := err.(Redactor); ok {
+		return rl.WithField("error", err)
+	}
+	if rl.errorNeedsRedaction(err) {
+		return rl.wrap(rl.logger.WithField("error", errorValue{err: err, redact: rl.redactError}))
+	}
+
+	return rl.wrap(rl.logger.WithErr(err))
+}
+
+func (rl *RedactingLogger) With(fields Fields) Logger {
+	return rl.wrap(rl.logger.With(rl.redactFields(fields)))
+}
+
+func (rl *RedactingLogger) Enabled(level LogLevel) bool {
+	return rl.logger.Enabled(level)
+}
+
+func (rl *RedactingLogger) Debug(message string) {
+	rl.logger.Debug(rl.redactString(message))
+}
+
+func (rl *RedactingLogger) Info(message string) {
+	rl.logger.Info(rl.redactString(message))
+}
+
+func (rl *RedactingLogger) Error(message string) {
+	rl.logger.Error(rl.redactString(message))
+}
+
+func (rl *RedactingLogger) wrap(logger Logger) Logger {
+	return &RedactingLogger{
+		logger: logger,
+		rules:  rl.rules,
+	}
+}
+
+func (rl *RedactingLogger) redactFields(fields Fields) Fields {
+	redacted := make(Fields, len(fields))
+	for k, v := range fields {
+		redacted[k] = rl.redactField(k, v)
+	}
+
+	return redacted
+}
+
+func (rl *RedactingLogger) redactField(key string, value interface{}) interface{} {
+	for _, re := range rl.rules.Keys {
+		if re.MatchString(key) {
+			return rl.rules.Mask
+		}
+	}
+
+	return rl.redactValue(value)
+}
+
+func (rl *RedactingLogger) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case LazyValue:
+		return LazyValue(func() interface{} {
+			return rl.redactValue(v())
+		})
+	case Redactor:
+		return v.Redact()
+	case string:
+		return rl.redactString(v)
+	case Fields:
+		return rl.redactFields(v)
+	case map[string]interface{}:
+		return map[string]interface{}(rl.redactFields(v))
+	case error, fmt.Stringer:
+		if s, ok := textValue(v); ok && rl.redactString(s) != s {
+			return rl.redactString(s)
+		}
+	}
+
+	return value
+}
+
+// errorNeedsRedaction reports whether err or one of the errors it wraps
+// has fields or a message that the rules change.
+func (rl *RedactingLogger) errorNeedsRedaction(err error) bool {
+	if err == nil {
+		return false
+	}
+	s, ok := textValue(err)
+	if !ok {
+		// a nil pointer, expandError stops here as well
+		return false
+	}
+	if _, ok := err.(ErrorWithFields); ok || rl.redactString(s) != s {
+		return true
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, cause := range e.Unwrap() {
+			if rl.errorNeedsRedaction(cause) {
+				return true
+			}
+		}
+	case interface{ Unwrap() error }:
+		return rl.errorNeedsRedaction(e.Unwrap())
+	}
+
+	return false
+}
+
+// redactError redacts the messages and fields of an expanded error, and of
+// the errors in its chain and causes.
+func (rl *RedactingLogger) redactError(m map[string]interface{}) {
+	if s, ok := m["message"].(string); ok {
+		m["message"] = rl.redactString(s)
+	}
+	if fields, ok := m["fields"].(map[string]interface{}); ok {
+		m["fields"] = map[string]interface{}(rl.redactFields(fields))
+	}
+	for _, key := range []string{"chain", "causes"} {
+		list, _ := m[key].([]interface{})
+		for _, e := range list {
+			if em, ok := e.(map[string]interface{}); ok {
+				rl.redactError(em)
+			}
+		}
+	}
+}
+
+func (rl *RedactingLogger) redactString(s string) string {
+	for _, re := range rl.rules.Values {
+		s = re.ReplaceAllString(s, rl.rules.Mask)
+	}
+	if rl.rules.CardNumbers {
+		s = cardNumberPattern.ReplaceAllStringFunc(s, func(match string) string {
+			if !luhnValid(match) {
+				return match
+			}
+			return rl.rules.Mask
+		})
+	}
+
+	return s
+}
+
+// luhnValid reports whether the digits in s have a valid Luhn check digit,
+// as card numbers do.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
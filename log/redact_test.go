@@ -0,0 +1,201 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+type testCredentials struct {
+	User     string
+	Password string
+}
+
+func (tc testCredentials) Redact() interface{} {
+	return testCredentials{User: tc.User, Password: "***"}
+}
+
+type testLoginError struct {
+	user     string
+	password string
+}
+
+func (tle *testLoginError) Error() string {
+	return "login failed"
+}
+
+func (tle *testLoginError) LogFields() log.Fields {
+	return log.Fields{"user": tle.user, "password": tle.password}
+}
+
+func TestRedactingLogger(t *testing.T) {
+	err := errors.New("unchanged")
+	for _, tc := range []struct {
+		name  string
+		key   string
+		value interface{}
+		exp   interface{}
+	}{
+		{
+			name:  "password key",
+			key:   "password",
+			value: "hunter2",
+			exp:   log.DefaultMask,
+		},
+		{
+			name:  "key case insensitive",
+			key:   "X-Auth-Token",
+			value: 12345,
+			exp:   log.DefaultMask,
+		},
+		{
+			name:  "authorization key",
+			key:   "Authorization",
+			value: "Basic dXNlcjpwYXNz",
+			exp:   log.DefaultMask,
+		},
+		{
+			name:  "credit card",
+			key:   "note",
+			value: "paid with 4111 1111 1111 1111 yesterday",
+			exp:   "paid with [REDACTED] yesterday",
+		},
+		{
+			name:  "timestamp",
+			key:   "note",
+			value: "sent at 1712345678901 as order 1700000000000000000",
+			exp:   "sent at 1712345678901 as order 1700000000000000000",
+		},
+		{
+			name:  "bearer token",
+			key:   "header",
+			value: "Bearer eyJhbGciOi.eyJzdWIiOi.SflKxwRJ",
+			exp:   log.DefaultMask,
+		},
+		{
+			name:  "error with token",
+			key:   "error",
+			value: errors.New("request failed: bearer abc.def"),
+			exp:   "request failed: [REDACTED]",
+		},
+		{
+			name:  "error without token",
+			key:   "error",
+			value: err,
+			exp:   err,
+		},
+		{
+			name:  "redactor",
+			key:   "credentials",
+			value: testCredentials{User: "user", Password: "hunter2"},
+			exp:   testCredentials{User: "user", Password: "***"},
+		},
+		{
+			name:  "nested",
+			key:   "request",
+			value: log.Fields{"secret": "s", "id": 1},
+			exp:   log.Fields{"secret": log.DefaultMask, "id": 1},
+		},
+		{
+			name:  "plain",
+			key:   "id",
+			value: 42,
+			exp:   42,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := log.NewTestOut()
+			logger := log.NewRedactingLogger(log.NewTestLogger(out), log.DefaultRedactRules)
+
+			logger.WithField(tc.key, tc.value).Info("message")
+
+			test.Equals(t, 1, len(out.Lines))
+			test.Equals(t, log.Fields{tc.key: tc.exp}, out.Lines[0].Fields)
+		})
+	}
+}
+
+func TestRedactingLoggerMessage(t *testing.T) {
+	out := log.NewTestOut()
+	logger := log.NewRedactingLogger(log.NewTestLogger(out), log.RedactRules{
+		Values:      log.DefaultRedactRules.Values,
+		CardNumbers: true,
+		Mask:        "xxx",
+	})
+
+	logger.WithGroup("http").Error("card 4111-1111-1111-1111 declined")
+
+	test.Equals(t, 1, len(out.Lines))
+	test.Equals(t, "card xxx declined", out.Lines[0].Message)
+}
+
+func TestRedactingLoggerNilError(t *testing.T) {
+	out := log.NewTestOut()
+	logger := log.NewRedactingLogger(log.NewTestLogger(out), log.DefaultRedactRules)
+
+	var err *testStackError
+	logger.WithErr(err).WithField("cause", err).Error("message")
+
+	test.Equals(t, 1, len(out.Lines))
+	test.Equals(t, "message", out.Lines[0].Message)
+}
+
+func TestRedactingLoggerErrorFields(t *testing.T) {
+	loginErr := &testLoginError{user: "user", password: "hunter2"}
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		exp  string
+	}{
+		{
+			name: "direct",
+			err:  loginErr,
+			exp:  `{"fields":{"password":"[REDACTED]","user":"user"},"message":"login failed","type":"*log_test.testLoginError"}`,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("call with bearer abc.def: %w", loginErr),
+			exp: `{"chain":[` +
+				`{"fields":{"password":"[REDACTED]","user":"user"},"message":"login failed","type":"*log_test.testLoginError"}` +
+				`],"message":"call with [REDACTED]: login failed","type":"*fmt.wrapError"}`,
+		},
+		{
+			name: "joined",
+			err:  errors.Join(errors.New("retry"), loginErr),
+			exp: `{"causes":[` +
+				`{"message":"retry","type":"*errors.errorString"},` +
+				`{"fields":{"password":"[REDACTED]","user":"user"},"message":"login failed","type":"*log_test.testLoginError"}` +
+				`],"message":"retry\nlogin failed","type":"*errors.joinError"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWriter{}
+			logger := log.NewRedactingLogger(log.NewGoKitIOLogger(tw), log.DefaultRedactRules)
+
+			logger.WithErr(tc.err).Error("message")
+
+			test.Equals(t, 1, len(tw.LogLines))
+			test.NotIncludes(t, "hunter2", tw.LogLines[0])
+			line := map[string]json.RawMessage{}
+			test.OK(t, json.Unmarshal([]byte(tw.LogLines[0]), &line))
+			test.Equals(t, tc.exp, string(line["error"]))
+		})
+	}
+
+	t.Run("test logger", func(t *testing.T) {
+		out := log.NewTestOut()
+		logger := log.NewRedactingLogger(log.NewTestLogger(out), log.DefaultRedactRules)
+
+		logger.WithErr(loginErr).Info("message")
+
+		test.Equals(t, 1, len(out.Lines))
+		expanded, ok := out.Lines[0].Fields["error"].(map[string]interface{})
+		test.Assert(t, ok, "error is not expanded")
+		test.Equals(t, map[string]interface{}{"password": log.DefaultMask, "user": "user"}, expanded["fields"])
+	})
+}
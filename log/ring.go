@@ -0,0 +1,364 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RingEntry is a line that is kept by a RingBuffer.
+type RingEntry struct {
+	Time    time.Time `json:"time"`
+	Level   LogLevel  `json:"level"`
+	Logger  string    `json:"logger,omitempty"`
+	Message string    `json:"message"`
+	Fields  Fields    `json:"fields,omitempty"`
+}
+
+// RingFilter selects entries of a RingBuffer. Level is the minimum level,
+// Message a substring of the message and Fields the values that fields
+// must have, compared as text. Empty parts match everything.
+type RingFilter struct {
+	Level   LogLevel
+	Message string
+	Fields  map[string]string
+}
+
+type ringState struct {
+	mu      sync.Mutex
+	entries []RingEntry
+	next    int
+	full    bool
+}
+
+// RingBuffer is a Logger that keeps the most recent lines in memory, for
+// inspection in a running process. Combine it with a Tee to write the lines
+// elsewhere as well. It serves the entries over HTTP, see ServeHTTP.
+type RingBuffer struct {
+	fields Fields
+	name   string
+	group  string
+	level  *AtomicLevel
+	state  *ringState
+}
+
+// NewRingBuffer returns a RingBuffer that keeps the last size entries. It
+// keeps lines of all levels, until the level is changed.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &RingBuffer{
+		fields: Fields{},
+		level:  NewAtomicLevel(LevelDebug),
+		state: &ringState{
+			entries: make([]RingEntry, size),
+		},
+	}
+}
+
+// SetLogLevel changes the default level, or on a named logger the level of
+// that name.
+func (rb *RingBuffer) SetLogLevel(loglevel LogLevel) {
+	rb.level.SetNamedLevel(rb.name, loglevel)
+}
+
+func (rb *RingBuffer) SetLevelSpec(spec LevelSpec) {
+	rb.level.SetSpec(spec)
+}
+
+func (rb *RingBuffer) Named(name string) Logger {
+	nb := rb.derive(nil)
+	nb.name = joinName(rb.name, name)
+
+	return nb
+}
+
+func (rb *RingBuffer) WithGroup(name string) Logger {
+	nb := rb.derive(nil)
+	if name != "" {
+		nb.group = rb.group + name + "."
+	}
+
+	return nb
+}
+
+func (rb *RingBuffer) WithField(key string, value interface{}) Logger {
+	return rb.derive(Fields{key: value})
+}
+
+// WithErr adds err as "error", expanded like in the JSON format of
+// GoKitIOLogger.
+func (rb *RingBuffer) WithErr(err error) Logger {
+	return rb.derive(Fields{"error": errorValue{err: err}})
+}
+
+func (rb *RingBuffer) With(fields Fields) Logger {
+	return rb.derive(fields)
+}
+
+func (rb *RingBuffer) Enabled(level LogLevel) bool {
+	return rb.level.Level(rb.name).allows(level)
+}
+
+func (rb *RingBuffer) Debug(message string) {
+	rb.log(LevelDebug, message)
+}
+
+func (rb *RingBuffer) Info(message string) {
+	rb.log(LevelInfo, message)
+}
+
+func (rb *RingBuffer) Error(message string) {
+	rb.log(LevelError, message)
+}
+
+// Entries returns the kept entries that match filter, oldest first.
+func (rb *RingBuffer) Entries(filter RingFilter) []RingEntry {
+	rs := rb.state
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var all []RingEntry
+	if rs.full {
+		all = append(all, rs.entries[rs.next:]...)
+	}
+	all = append(all, rs.entries[:rs.next]...)
+
+	entries := make([]RingEntry, 0, len(all))
+	for _, e := range all {
+		if filter.match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// ServeHTTP lists the entries as JSON, or as an HTML page when the format
+// parameter is "html" or the client accepts HTML. The parameters level and
+// message set the filter, field parameters in the form key=value add
+// required field values.
+func (rb *RingBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := RingFilter{
+		Message: query.Get("message"),
+		Fields:  map[string]string{},
+	}
+	if l := query.Get("level"); l != "" {
+		level, err := ParseLogLevel(l)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		filter.Level = level
+	}
+	for _, f := range query["field"] {
+		if f == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid field filter: %q", f)})
+			return
+		}
+		filter.Fields[key] = value
+	}
+	entries := rb.Entries(filter)
+
+	format := query.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		format = "html"
+	}
+	if format != "html" {
+		writeJSON(w, http.StatusOK, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	ringPage.Execute(w, ringPageData{
+		Filter:  filter,
+		Field:   query.Get("field"),
+		Entries: entries,
+	})
+}
+
+func (rb *RingBuffer) derive(fields Fields) *RingBuffer {
+	newFields := make(Fields, len(rb.fields)+len(fields))
+	for k, v := range rb.fields {
+		newFields[k] = v
+	}
+	for k, v := range fields {
+		newFields[rb.group+k] = v
+	}
+
+	return &RingBuffer{
+		fields: newFields,
+		name:   rb.name,
+		group:  rb.group,
+		level:  rb.level,
+		state:  rb.state,
+	}
+}
+
+func (rb *RingBuffer) log(level LogLevel, message string) {
+	if !rb.Enabled(level) {
+		return
+	}
+
+	e := RingEntry{
+		Time:    time.Now(),
+		Level:   level,
+		Logger:  rb.name,
+		Message: message,
+	}
+	if len(rb.fields) > 0 {
+		e.Fields = make(Fields, len(rb.fields))
+		for k, v := range rb.fields {
+			e.Fields[k] = ringValue(v)
+		}
+	}
+
+	rs := rb.state
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.entries[rs.next] = e
+	rs.next = (rs.next + 1) % len(rs.entries)
+	if rs.next == 0 {
+		rs.full = true
+	}
+}
+
+// ringValue resolves lazy values and turns errors and values that
+// encoding/json can not handle, like NaN or a func, into text, so that a
+// single value can not break the listing of all entries.
+func ringValue(value interface{}) interface{} {
+	if lv, ok := value.(LazyValue); ok {
+		value = lv()
+	}
+	switch v := value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case float32:
+		if f := float64(v); math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 32)
+		}
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case errorValue:
+		if m := v.expand(); m != nil {
+			return ringValue(m)
+		}
+		return nil
+	case error:
+		if s, ok := textValue(v); ok {
+			return s
+		}
+		return nil
+	}
+	if _, err := json.Marshal(value); err != nil {
+		if s, ok := textValue(value); ok {
+			return s
+		}
+		return nil
+	}
+
+	return value
+}
+
+func (rf RingFilter) match(e RingEntry) bool {
+	if rf.Level != "" && !rf.Level.allows(e.Level) {
+		return false
+	}
+	if !strings.Contains(e.Message, rf.Message) {
+		return false
+	}
+	for k, want := range rf.Fields {
+		if k == nameKey && e.Logger == want {
+			continue
+		}
+		value, ok := e.Fields[k]
+		if !ok {
+			return false
+		}
+		if m, isErr := value.(map[string]interface{}); isErr && m["message"] != nil {
+			// match errors on their message
+			value = m["message"]
+		}
+		if fmt.Sprint(value) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+type ringPageData struct {
+	Filter  RingFilter
+	Field   string
+	Entries []RingEntry
+}
+
+var ringPage = template.Must(template.New("ring").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05.000")
+	},
+	"fields": func(fields Fields) string {
+		parts := make([]string, 0, len(fields))
+		for _, k := range sortedKeys(fields) {
+			parts = append(parts, k+"="+strconv.Quote(fmt.Sprint(fields[k])))
+		}
+		return strings.Join(parts, " ")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Recent log entries</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 8px; text-align: left; vertical-align: top; }
+td.fields { font-family: monospace; }
+tr.error { color: #b00; }
+tr.debug { color: #777; }
+</style>
+</head>
+<body>
+<form method="get">
+<input type="hidden" name="format" value="html">
+<select name="level">
+<option value="" {{if eq .Filter.Level ""}}selected{{end}}>all</option>
+<option value="debug" {{if eq .Filter.Level "debug"}}selected{{end}}>debug</option>
+<option value="info" {{if eq .Filter.Level "info"}}selected{{end}}>info</option>
+<option value="error" {{if eq .Filter.Level "error"}}selected{{end}}>error</option>
+</select>
+<input type="text" name="message" placeholder="message" value="{{.Filter.Message}}">
+<input type="text" name="field" placeholder="key=value" value="{{.Field}}">
+<button type="submit">filter</button>
+</form>
+<table>
+<tr><th>time</th><th>level</th><th>logger</th><th>message</th><th>fields</th></tr>
+{{range .Entries}}<tr class="{{.Level}}"><td>{{time .Time}}</td><td>{{.Level}}</td><td>{{.Logger}}</td><td>{{.Message}}</td><td class="fields">{{fields .Fields}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
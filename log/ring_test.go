@@ -0,0 +1,159 @@
+package log_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestRingBuffer(t *testing.T) {
+	rb := log.NewRingBuffer(3)
+	logger := rb.Named("api").WithField("i", 0)
+
+	for _, m := range []string{"one", "two", "three", "four"} {
+		logger.Info(m)
+	}
+
+	entries := rb.Entries(log.RingFilter{})
+	test.Equals(t, 3, len(entries))
+	for i, exp := range []string{"two", "three", "four"} {
+		test.Equals(t, exp, entries[i].Message)
+		test.Equals(t, "api", entries[i].Logger)
+		test.Equals(t, log.Fields{"i": 0}, entries[i].Fields)
+	}
+}
+
+func TestRingBufferNilError(t *testing.T) {
+	rb := log.NewRingBuffer(2)
+	rb.WithErr((*testStackError)(nil)).Error("failed")
+	rb.WithField("cause", (*testStackError)(nil)).Error("failed")
+
+	entries := rb.Entries(log.RingFilter{})
+	test.Equals(t, 2, len(entries))
+	test.Equals(t, log.Fields{"error": nil}, entries[0].Fields)
+	test.Equals(t, log.Fields{"cause": nil}, entries[1].Fields)
+}
+
+func TestRingBufferFilter(t *testing.T) {
+	rb := log.NewRingBuffer(10)
+	rb.Debug("debug message")
+	rb.WithField("user", 3).Info("user logged in")
+	rb.WithField("user", 4).Info("user logged in")
+	rb.WithErr(errors.New("not found")).Error("request failed")
+
+	for _, tc := range []struct {
+		name   string
+		filter log.RingFilter
+		exp    []string
+	}{
+		{
+			name: "all",
+			exp:  []string{"debug message", "user logged in", "user logged in", "request failed"},
+		},
+		{
+			name:   "level",
+			filter: log.RingFilter{Level: log.LevelInfo},
+			exp:    []string{"user logged in", "user logged in", "request failed"},
+		},
+		{
+			name:   "message",
+			filter: log.RingFilter{Message: "message"},
+			exp:    []string{"debug message"},
+		},
+		{
+			name:   "field",
+			filter: log.RingFilter{Fields: map[string]string{"user": "4"}},
+			exp:    []string{"user logged in"},
+		},
+		{
+			name:   "error",
+			filter: log.RingFilter{Fields: map[string]string{"error": "not found"}},
+			exp:    []string{"request failed"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var messages []string
+			for _, e := range rb.Entries(tc.filter) {
+				messages = append(messages, e.Message)
+			}
+			test.Equals(t, tc.exp, messages)
+		})
+	}
+}
+
+func TestRingBufferUnsupportedValues(t *testing.T) {
+	rb := log.NewRingBuffer(10)
+	rb.Info("first")
+	rb.With(log.Fields{
+		"nan":    math.NaN(),
+		"inf":    math.Inf(1),
+		"func":   func() {},
+		"chan":   make(chan int),
+		"nested": map[string]interface{}{"nan": math.NaN()},
+	}).Info("second")
+
+	rec := httptest.NewRecorder()
+	rb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	test.Equals(t, http.StatusOK, rec.Code)
+	var entries []log.RingEntry
+	test.OK(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	test.Equals(t, 2, len(entries))
+	test.Equals(t, "NaN", entries[1].Fields["nan"])
+	test.Equals(t, "+Inf", entries[1].Fields["inf"])
+	test.Equals(t, "map[nan:NaN]", entries[1].Fields["nested"])
+	_, isText := entries[1].Fields["func"].(string)
+	test.Assert(t, isText, "expected func as text")
+	_, isText = entries[1].Fields["chan"].(string)
+	test.Assert(t, isText, "expected chan as text")
+}
+
+func TestRingBufferHTTP(t *testing.T) {
+	rb := log.NewRingBuffer(10)
+	rb.WithField("user", 3).Info("user <b>logged</b> in")
+	rb.WithErr(errors.New("not found")).Error("request failed")
+
+	t.Run("json", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=info&field=user=3", nil))
+
+		test.Equals(t, http.StatusOK, rec.Code)
+		test.Equals(t, "application/json", rec.Header().Get("Content-Type"))
+		var entries []log.RingEntry
+		test.OK(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		test.Equals(t, 1, len(entries))
+		test.Equals(t, "user <b>logged</b> in", entries[0].Message)
+		test.Equals(t, log.Fields{"user": float64(3)}, entries[0].Fields)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rb.ServeHTTP(rec, req)
+
+		test.Equals(t, http.StatusOK, rec.Code)
+		test.Equals(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		test.Includes(t, "user &lt;b&gt;logged&lt;/b&gt; in", rec.Body.String())
+		test.Includes(t, `<tr class="error">`, rec.Body.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, target := range []string{"/?level=warning", "/?field=user"} {
+			rec := httptest.NewRecorder()
+			rb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			test.Equals(t, http.StatusBadRequest, rec.Code)
+		}
+
+		rec := httptest.NewRecorder()
+		rb.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+		test.Equals(t, http.StatusMethodNotAllowed, rec.Code)
+		test.Equals(t, "GET", rec.Header().Get("Allow"))
+	})
+}
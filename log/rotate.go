@@ -0,0 +1,347 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+var ErrInvalidRotateConfig = errors.New("invalid rotate configuration")
+
+// RotateConfig configures a RotatingFile. The file is rotated when a write
+// would make it larger than MaxSize bytes and, with Daily set, on the first
+// write of a new day. Rotated files get a timestamp in their name, like
+// app-20061017T150405.000.log, and are gzipped when Compress is set.
+// MaxBackups and MaxAge limit the rotated files that are kept, zero means
+// no limit. Errors of a rotation that is triggered by a write do not fail
+// that write, they are passed to OnError, or written to stderr if it is not
+// set. OnError is called after the file is unlocked, so it may log to a
+// logger that writes to the file.
+type RotateConfig struct {
+	Filename   string
+	MaxSize    int64
+	Daily      bool
+	Compress   bool
+	MaxBackups int
+	MaxAge     time.Duration
+	OnError    func(error)
+}
+
+// RotatingFile is an io.Writer that writes to a file and rotates it
+// according to its configuration. Rotation, compression and cleanup happen
+// during the write that triggers them, use an AsyncWriter in front of it to
+// keep them out of the request path.
+type RotatingFile struct {
+	mu     sync.Mutex
+	config RotateConfig
+	file   *os.File
+	size   int64
+	day    string
+	closed bool
+}
+
+func NewRotatingFile(config RotateConfig) (*RotatingFile, error) {
+	if config.Filename == "" || config.MaxSize < 0 || config.MaxBackups < 0 || config.MaxAge < 0 {
+		return nil, ErrInvalidRotateConfig
+	}
+
+	rf := &RotatingFile{
+		config: config,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+// Write writes p, after a rotation if one is due. Errors of the rotation
+// are reported after the file is unlocked, so OnError can log to a logger
+// that writes to this file.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	n, rotateErr, err := rf.write(p)
+	if rotateErr != nil {
+		rf.report(rotateErr)
+	}
+
+	return n, err
+}
+
+func (rf *RotatingFile) write(p []byte) (n int, rotateErr, err error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, nil, ErrWriterClosed
+	}
+	if rf.file == nil {
+		// an earlier rotation could not open the file again
+		if err := rf.open(); err != nil {
+			return 0, nil, err
+		}
+	}
+	sizeExceeded := rf.config.MaxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.config.MaxSize
+	dayPassed := rf.config.Daily && rf.day != time.Now().Format(time.DateOnly)
+	if sizeExceeded || dayPassed {
+		if rotateErr = rf.rotate(); rotateErr != nil && rf.file == nil {
+			return 0, nil, rotateErr
+		}
+	}
+
+	n, err = rf.file.Write(p)
+	rf.size += int64(n)
+
+	return n, rotateErr, err
+}
+
+// Rotate rotates the file regardless of the configured policies. Unlike
+// with a rotation during a write, errors are returned.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return ErrWriterClosed
+	}
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return err
+		}
+	}
+
+	return rf.rotate()
+}
+
+// Reopen closes the file and opens it again by name. This is needed when an
+// external tool like logrotate has moved the file.
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return ErrWriterClosed
+	}
+	if rf.file != nil {
+		if err := rf.file.Close(); err != nil {
+			return err
+		}
+		rf.file = nil
+	}
+
+	return rf.open()
+}
+
+// ReopenOnSignal calls Reopen every time one of the signals is received,
+// SIGHUP if none are given. The returned function stops listening.
+func (rf *RotatingFile) ReopenOnSignal(sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				rf.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return ErrWriterClosed
+	}
+	rf.closed = true
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+
+	return err
+}
+
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.config.Filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(rf.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.day = time.Now().Format(time.DateOnly)
+	if rf.size > 0 {
+		rf.day = info.ModTime().Format(time.DateOnly)
+	}
+
+	return nil
+}
+
+// rotate moves the file aside and opens a new one. Whatever fails, the file
+// is opened again by name, so that writing can continue. Only when that is
+// not possible either, no file is left open.
+func (rf *RotatingFile) rotate() error {
+	var errs []error
+	if err := rf.file.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	rf.file = nil
+
+	ext := filepath.Ext(rf.config.Filename)
+	prefix := strings.TrimSuffix(rf.config.Filename, ext) + "-"
+	ts := time.Now()
+	backup := prefix + ts.Format(backupTimeFormat) + ext
+	for fileExists(backup) || fileExists(backup+".gz") {
+		ts = ts.Add(time.Millisecond)
+		backup = prefix + ts.Format(backupTimeFormat) + ext
+	}
+	if err := os.Rename(rf.config.Filename, backup); err != nil {
+		errs = append(errs, fmt.Errorf("could not rotate %s: %w", rf.config.Filename, err))
+		return errors.Join(append(errs, rf.open())...)
+	}
+	if err := rf.open(); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	if rf.config.Compress {
+		if err := compressFile(backup); err != nil {
+			errs = append(errs, fmt.Errorf("could not compress %s: %w", backup, err))
+		}
+	}
+	if err := rf.cleanup(); err != nil {
+		errs = append(errs, fmt.Errorf("could not remove old files: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (rf *RotatingFile) report(err error) {
+	if rf.config.OnError != nil {
+		rf.config.OnError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "log: %v\n", err)
+}
+
+// cleanup removes the rotated files that exceed MaxBackups or MaxAge.
+func (rf *RotatingFile) cleanup() error {
+	if rf.config.MaxBackups == 0 && rf.config.MaxAge == 0 {
+		return nil
+	}
+
+	type backupFile struct {
+		path string
+		ts   time.Time
+	}
+	ext := filepath.Ext(rf.config.Filename)
+	prefix := strings.TrimSuffix(filepath.Base(rf.config.Filename), ext) + "-"
+	dir := filepath.Dir(rf.config.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext), prefix)
+		ts, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), ts: ts})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ts.After(backups[j].ts)
+	})
+
+	var errs []error
+	for i, b := range backups {
+		tooMany := rf.config.MaxBackups > 0 && i >= rf.config.MaxBackups
+		tooOld := rf.config.MaxAge > 0 && time.Since(b.ts) > rf.config.MaxAge
+		if tooMany || tooOld {
+			errs = append(errs, os.Remove(b.path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func compressFile(path string) error {
+	if err := gzipFile(path, path+".gz.tmp"); err != nil {
+		os.Remove(path + ".gz.tmp")
+		return err
+	}
+	if err := os.Rename(path+".gz.tmp", path+".gz"); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func gzipFile(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	return dst.Close()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
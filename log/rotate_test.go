@@ -0,0 +1,207 @@
+package log_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	test.OK(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	test.OK(t, err)
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		test.OK(t, err)
+		r = zr
+	}
+	b, err := io.ReadAll(r)
+	test.OK(t, err)
+
+	return string(b)
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	rf, err := log.NewRotatingFile(log.RotateConfig{
+		Filename: filename,
+		MaxSize:  10,
+	})
+	test.OK(t, err)
+	defer rf.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := rf.Write([]byte(line))
+		test.OK(t, err)
+	}
+
+	names := listDir(t, dir)
+	test.Equals(t, 3, len(names))
+	test.Equals(t, "app.log", names[2])
+	test.Equals(t, "third\n", readFile(t, filename))
+	test.Equals(t, "first\n", readFile(t, filepath.Join(dir, names[0])))
+	test.Equals(t, "second\n", readFile(t, filepath.Join(dir, names[1])))
+}
+
+func TestRotatingFileCompressAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	rf, err := log.NewRotatingFile(log.RotateConfig{
+		Filename:   filename,
+		Compress:   true,
+		MaxBackups: 2,
+	})
+	test.OK(t, err)
+	defer rf.Close()
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		_, err := rf.Write([]byte(line))
+		test.OK(t, err)
+		test.OK(t, rf.Rotate())
+	}
+
+	names := listDir(t, dir)
+	test.Equals(t, 3, len(names))
+	test.Equals(t, "app.log", names[2])
+	test.Assert(t, strings.HasPrefix(names[0], "app-") && strings.HasSuffix(names[0], ".log.gz"), "unexpected name", names[0])
+	test.Equals(t, "3\n", readFile(t, filepath.Join(dir, names[0])))
+	test.Equals(t, "4\n", readFile(t, filepath.Join(dir, names[1])))
+}
+
+func TestRotatingFileDaily(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	test.OK(t, os.WriteFile(filename, []byte("yesterday\n"), 0644))
+	yesterday := time.Now().Add(-24 * time.Hour)
+	test.OK(t, os.Chtimes(filename, yesterday, yesterday))
+
+	rf, err := log.NewRotatingFile(log.RotateConfig{
+		Filename: filename,
+		Daily:    true,
+	})
+	test.OK(t, err)
+	defer rf.Close()
+
+	_, err = rf.Write([]byte("today\n"))
+	test.OK(t, err)
+	_, err = rf.Write([]byte("today again\n"))
+	test.OK(t, err)
+
+	names := listDir(t, dir)
+	test.Equals(t, 2, len(names))
+	test.Equals(t, "yesterday\n", readFile(t, filepath.Join(dir, names[0])))
+	test.Equals(t, "today\ntoday again\n", readFile(t, filename))
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	rf, err := log.NewRotatingFile(log.RotateConfig{Filename: filename})
+	test.OK(t, err)
+	defer rf.Close()
+
+	_, err = rf.Write([]byte("before\n"))
+	test.OK(t, err)
+	test.OK(t, os.Rename(filename, filename+".1"))
+	test.OK(t, rf.Reopen())
+	_, err = rf.Write([]byte("after\n"))
+	test.OK(t, err)
+
+	test.Equals(t, "before\n", readFile(t, filename+".1"))
+	test.Equals(t, "after\n", readFile(t, filename))
+}
+
+func TestRotatingFileRotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	var errs []error
+	rf, err := log.NewRotatingFile(log.RotateConfig{
+		Filename: filename,
+		MaxSize:  12,
+		OnError: func(err error) {
+			errs = append(errs, err)
+		},
+	})
+	test.OK(t, err)
+	defer rf.Close()
+
+	_, err = rf.Write([]byte("before\n"))
+	test.OK(t, err)
+	// moved away without a reopen, so the rotation can not rename it
+	test.OK(t, os.Rename(filename, filepath.Join(dir, "moved.log")))
+
+	n, err := rf.Write([]byte("triggers\n"))
+	test.OK(t, err)
+	test.Equals(t, 9, n)
+	test.Equals(t, 1, len(errs))
+	test.Includes(t, "could not rotate", errs[0].Error())
+
+	_, err = rf.Write([]byte("ok\n"))
+	test.OK(t, err)
+	test.Equals(t, 1, len(errs))
+	test.Equals(t, "before\n", readFile(t, filepath.Join(dir, "moved.log")))
+	test.Equals(t, "triggers\nok\n", readFile(t, filename))
+}
+
+func TestRotatingFileOnErrorLogs(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	var logger log.Logger
+	rf, err := log.NewRotatingFile(log.RotateConfig{
+		Filename: filename,
+		MaxSize:  3000,
+		OnError: func(err error) {
+			logger.WithErr(err).Error("could not rotate log file")
+		},
+	})
+	test.OK(t, err)
+	defer rf.Close()
+	logger = log.New(rf)
+
+	logger.Info(strings.Repeat("b", 2500))
+	test.OK(t, os.Rename(filename, filepath.Join(dir, "moved.log")))
+
+	done := make(chan struct{})
+	go func() {
+		logger.Info(strings.Repeat("x", 600))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("write did not return")
+	}
+
+	lines := strings.Split(strings.TrimSpace(readFile(t, filename)), "\n")
+	test.Equals(t, 2, len(lines))
+	test.Includes(t, "xxx", lines[0])
+	test.Includes(t, "could not rotate log file", lines[1])
+}
+
+func TestRotatingFileInvalidConfig(t *testing.T) {
+	_, err := log.NewRotatingFile(log.RotateConfig{})
+	test.Equals(t, log.ErrInvalidRotateConfig, err)
+}
@@ -0,0 +1,242 @@
+package log
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SamplerConfig configures a sampler. Within every Interval the First
+// occurrences of a message at a level are logged, after that only every
+// Thereafter-th one. With Thereafter zero all further occurrences are
+// dropped. Interval defaults to one second and First to one.
+type SamplerConfig struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+}
+
+type samplerKey struct {
+	name    string
+	level   LogLevel
+	message string
+}
+
+// samplerCount keeps the logger of the last suppressed entry, to write the
+// summary with its name and fields.
+type samplerCount struct {
+	seen       int
+	suppressed int
+	logger     Logger
+}
+
+type samplerSummary struct {
+	key        samplerKey
+	suppressed int
+	logger     Logger
+}
+
+type samplerState struct {
+	mu     sync.Mutex
+	config SamplerConfig
+	start  time.Time
+	counts map[samplerKey]*samplerCount
+	timer  *time.Timer
+	closed bool
+}
+
+// Sampler is a Logger that limits the number of identical messages that
+// are passed to the wrapped logger. Messages are counted per name, so
+// loggers with different names have their own budget. At the end of an
+// interval in which entries were suppressed, it reports the number of
+// suppressed entries per message in a summary line, written by the logger
+// of the suppressed entries. Close stops the timer for these summaries and
+// writes the pending ones.
+type Sampler struct {
+	logger Logger
+	name   string
+	state  *samplerState
+}
+
+func NewSampler(logger Logger, config SamplerConfig) *Sampler {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.First <= 0 {
+		config.First = 1
+	}
+
+	return &Sampler{
+		logger: logger,
+		state: &samplerState{
+			config: config,
+			start:  time.Now(),
+			counts: make(map[samplerKey]*samplerCount),
+		},
+	}
+}
+
+func (s *Sampler) SetLogLevel(loglevel LogLevel) {
+	s.logger.SetLogLevel(loglevel)
+}
+
+func (s *Sampler) Named(name string) Logger {
+	ns := s.wrap(s.logger.Named(name))
+	ns.name = joinName(s.name, name)
+
+	return ns
+}
+
+func (s *Sampler) WithGroup(name string) Logger {
+	return s.wrap(s.logger.WithGroup(name))
+}
+
+func (s *Sampler) WithField(key string, value interface{}) Logger {
+	return s.wrap(s.logger.WithField(key, value))
+}
+
+func (s *Sampler) WithErr(err error) Logger {
+	return s.wrap(s.logger.WithErr(err))
+}
+
+func (s *Sampler) With(fields Fields) Logger {
+	return s.wrap(s.logger.With(fields))
+}
+
+func (s *Sampler) Enabled(level LogLevel) bool {
+	return s.logger.Enabled(level)
+}
+
+func (s *Sampler) Debug(message string) {
+	s.log(LevelDebug, message)
+}
+
+func (s *Sampler) Info(message string) {
+	s.log(LevelInfo, message)
+}
+
+func (s *Sampler) Error(message string) {
+	s.log(LevelError, message)
+}
+
+func (s *Sampler) wrap(logger Logger) *Sampler {
+	return &Sampler{
+		logger: logger,
+		name:   s.name,
+		state:  s.state,
+	}
+}
+
+// Flush writes the summaries of the current interval right away and starts
+// a new one.
+func (s *Sampler) Flush() {
+	s.state.mu.Lock()
+	summaries := s.state.rollover(time.Now())
+	s.state.mu.Unlock()
+
+	s.state.summarize(summaries)
+}
+
+// Close writes the pending summaries. Entries that are suppressed after
+// Close are only reported when a later call ends the interval.
+func (s *Sampler) Close() {
+	s.state.mu.Lock()
+	s.state.closed = true
+	summaries := s.state.rollover(time.Now())
+	s.state.mu.Unlock()
+
+	s.state.summarize(summaries)
+}
+
+func (s *Sampler) log(level LogLevel, message string) {
+	summaries, pass := s.state.sample(samplerKey{name: s.name, level: level, message: message}, s.logger, time.Now())
+	s.state.summarize(summaries)
+	if pass {
+		logAt(s.logger, level, message)
+	}
+}
+
+// sample counts an occurrence of key and reports whether it should be
+// logged. If the interval has passed, it starts a new one and returns the
+// suppressed counts of the previous one. The first suppressed entry of an
+// interval schedules the summaries for its end.
+func (ss *samplerState) sample(key samplerKey, logger Logger, now time.Time) ([]samplerSummary, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var summaries []samplerSummary
+	if now.Sub(ss.start) >= ss.config.Interval {
+		summaries = ss.rollover(now)
+	}
+
+	c, ok := ss.counts[key]
+	if !ok {
+		c = &samplerCount{}
+		ss.counts[key] = c
+	}
+	c.seen++
+	if c.seen <= ss.config.First {
+		return summaries, true
+	}
+	if ss.config.Thereafter > 0 && (c.seen-ss.config.First)%ss.config.Thereafter == 0 {
+		return summaries, true
+	}
+	c.suppressed++
+	c.logger = logger
+	if ss.timer == nil && !ss.closed {
+		start := ss.start
+		ss.timer = time.AfterFunc(start.Add(ss.config.Interval).Sub(now), func() {
+			ss.mu.Lock()
+			if !ss.start.Equal(start) {
+				// the interval was already ended by a call or a flush
+				ss.mu.Unlock()
+				return
+			}
+			summaries := ss.rollover(time.Now())
+			ss.mu.Unlock()
+
+			ss.summarize(summaries)
+		})
+	}
+
+	return summaries, false
+}
+
+// rollover starts a new interval and returns the suppressed counts of the
+// previous one, sorted by level, name and message. The caller must hold the lock.
+func (ss *samplerState) rollover(now time.Time) []samplerSummary {
+	var summaries []samplerSummary
+	for k, c := range ss.counts {
+		if c.suppressed == 0 {
+			continue
+		}
+		summaries = append(summaries, samplerSummary{key: k, suppressed: c.suppressed, logger: c.logger})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i].key, summaries[j].key
+		if a.level != b.level {
+			return levelRank(a.level) < levelRank(b.level)
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.message < b.message
+	})
+	ss.counts = make(map[samplerKey]*samplerCount)
+	ss.start = now
+	if ss.timer != nil {
+		ss.timer.Stop()
+		ss.timer = nil
+	}
+
+	return summaries
+}
+
+func (ss *samplerState) summarize(summaries []samplerSummary) {
+	for _, sum := range summaries {
+		logAt(sum.logger.With(Fields{
+			"sampled_message": sum.key.message,
+			"suppressed":      sum.suppressed,
+		}), sum.key.level, "suppressed repeated log entries")
+	}
+}
@@ -0,0 +1,143 @@
+package log_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestSampler(t *testing.T) {
+	out := log.NewTestOut()
+	logger := log.NewSampler(log.NewTestLogger(out), log.SamplerConfig{
+		Interval:   time.Hour,
+		First:      2,
+		Thereafter: 3,
+	})
+
+	for i := 0; i < 10; i++ {
+		logger.WithField("i", i).Error("failed")
+		logger.Info("other")
+	}
+
+	var failed, other []log.TestLine
+	for _, l := range out.Lines {
+		switch l.Message {
+		case "failed":
+			failed = append(failed, l)
+		case "other":
+			other = append(other, l)
+		}
+	}
+	test.Equals(t, 4, len(failed))
+	for i, exp := range []int{0, 1, 4, 7} {
+		test.Equals(t, log.Fields{"i": exp}, failed[i].Fields)
+	}
+	test.Equals(t, 4, len(other))
+}
+
+func TestSamplerSummary(t *testing.T) {
+	sw := &syncWriter{}
+	logger := log.NewSampler(log.New(sw), log.SamplerConfig{
+		Interval: 50 * time.Millisecond,
+	})
+
+	for i := 0; i < 5; i++ {
+		logger.Error("failed")
+	}
+	test.Equals(t, 1, strings.Count(sw.String(), "\n"))
+
+	time.Sleep(60 * time.Millisecond)
+	logger.Error("failed")
+
+	lines := strings.Split(strings.TrimSpace(sw.String()), "\n")
+	test.Equals(t, 3, len(lines))
+	summary := map[string]interface{}{}
+	test.OK(t, json.Unmarshal([]byte(lines[1]), &summary))
+	test.IncludesMap(t, map[string]interface{}{
+		"level":           "error",
+		"message":         "suppressed repeated log entries",
+		"sampled_message": "failed",
+		"suppressed":      float64(4),
+	}, summary)
+	test.Includes(t, `"message":"failed"`, lines[2])
+}
+
+func TestSamplerSummaryTimer(t *testing.T) {
+	sw := &syncWriter{}
+	logger := log.NewSampler(log.New(sw), log.SamplerConfig{
+		Interval: 20 * time.Millisecond,
+	})
+	defer logger.Close()
+
+	for i := 0; i < 3; i++ {
+		logger.Error("failed")
+	}
+
+	// the burst has stopped, the summary is written without further calls
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(sw.String(), "suppressed repeated log entries") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	lines := strings.Split(strings.TrimSpace(sw.String()), "\n")
+	test.Equals(t, 2, len(lines))
+	test.Includes(t, `"suppressed":2`, lines[1])
+}
+
+func TestSamplerFlush(t *testing.T) {
+	out := log.NewTestOut()
+	logger := log.NewSampler(log.NewTestLogger(out), log.SamplerConfig{
+		Interval: time.Hour,
+	})
+
+	for i := 0; i < 2; i++ {
+		logger.Error("b")
+		logger.Info("c")
+		logger.Error("a")
+		logger.Debug("d")
+	}
+	out.Flush()
+	logger.Flush()
+
+	var summaries []string
+	for _, l := range out.Lines {
+		summaries = append(summaries, string(l.Level)+" "+l.Fields["sampled_message"].(string))
+	}
+	test.Equals(t, []string{"debug d", "info c", "error a", "error b"}, summaries)
+
+	// nothing is pending after a flush
+	out.Flush()
+	logger.Close()
+	test.Equals(t, 0, len(out.Lines))
+}
+
+func TestSamplerNamed(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewSampler(log.NewGoKitIOLogger(tw), log.SamplerConfig{
+		Interval: time.Hour,
+	})
+	db := logger.Named("db").WithField("shard", 2)
+	http := logger.Named("http")
+
+	for i := 0; i < 3; i++ {
+		db.Error("timeout")
+		http.Error("timeout")
+	}
+	test.Equals(t, 2, len(tw.LogLines))
+
+	tw.Flush()
+	logger.Flush()
+	test.Equals(t, 2, len(tw.LogLines))
+	for i, exp := range []map[string]interface{}{
+		{"logger": "db", "shard": float64(2), "sampled_message": "timeout", "suppressed": float64(2)},
+		{"logger": "http", "sampled_message": "timeout", "suppressed": float64(2)},
+	} {
+		summary := map[string]interface{}{}
+		test.OK(t, json.Unmarshal([]byte(tw.LogLines[i]), &summary))
+		test.IncludesMap(t, exp, summary)
+		test.Equals(t, "suppressed repeated log entries", summary["message"])
+	}
+}
@@ -0,0 +1,207 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogHandler is a slog.Handler that writes to a Logger. Slog levels below
+// info are logged as debug, levels below error as info and the rest as
+// error. Attributes become fields, groups are flattened into dotted keys.
+type SlogHandler struct {
+	logger Logger
+	prefix string
+}
+
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &SlogHandler{
+		logger: logger,
+	}
+}
+
+func (sh *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return sh.logger.Enabled(fromSlogLevel(level))
+}
+
+func (sh *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(Fields)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, sh.prefix, a)
+		return true
+	})
+
+	logger := sh.logger
+	if len(fields) > 0 {
+		logger = logger.With(fields)
+	}
+
+	logAt(logger, fromSlogLevel(r.Level), r.Message)
+
+	return nil
+}
+
+func (sh *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields)
+	for _, a := range attrs {
+		addAttr(fields, sh.prefix, a)
+	}
+	if len(fields) == 0 {
+		return sh
+	}
+
+	return &SlogHandler{
+		logger: sh.logger.With(fields),
+		prefix: sh.prefix,
+	}
+}
+
+func (sh *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return sh
+	}
+
+	return &SlogHandler{
+		logger: sh.logger,
+		prefix: sh.prefix + name + ".",
+	}
+}
+
+func fromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelError:
+		return LevelInfo
+	default:
+		return LevelError
+	}
+}
+
+func toSlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func addAttr(fields Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, groupPrefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+
+	fields[prefix+a.Key] = a.Value.Any()
+}
+
+// SlogLogger is a Logger that writes to a slog.Handler. The log level
+// defaults to debug, so that filtering is left to the handler.
+type SlogLogger struct {
+	handler slog.Handler
+	name    string
+	level   *AtomicLevel
+}
+
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &SlogLogger{
+		handler: handler,
+		level:   NewAtomicLevel(LevelDebug),
+	}
+}
+
+// SetLogLevel changes the default level, or on a named logger the level of
+// that name.
+func (sl *SlogLogger) SetLogLevel(loglevel LogLevel) {
+	sl.level.SetNamedLevel(sl.name, loglevel)
+}
+
+func (sl *SlogLogger) SetLevelSpec(spec LevelSpec) {
+	sl.level.SetSpec(spec)
+}
+
+func (sl *SlogLogger) Named(name string) Logger {
+	fullName := joinName(sl.name, name)
+	nl := sl.With(Fields{
+		"logger": fullName,
+	}).(*SlogLogger)
+	nl.name = fullName
+
+	return nl
+}
+
+func (sl *SlogLogger) WithGroup(name string) Logger {
+	if name == "" {
+		return sl
+	}
+
+	return &SlogLogger{
+		handler: sl.handler.WithGroup(name),
+		name:    sl.name,
+		level:   sl.level,
+	}
+}
+
+func (sl *SlogLogger) WithField(key string, value interface{}) Logger {
+	return sl.With(Fields{
+		key: value,
+	})
+}
+
+func (sl *SlogLogger) WithErr(err error) Logger {
+	return sl.With(Fields{
+		"error": err,
+	})
+}
+
+func (sl *SlogLogger) With(fields Fields) Logger {
+	keys := sortedKeys(fields)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+
+	return &SlogLogger{
+		handler: sl.handler.WithAttrs(attrs),
+		name:    sl.name,
+		level:   sl.level,
+	}
+}
+
+func (sl *SlogLogger) Debug(message string) {
+	sl.log(LevelDebug, message)
+}
+
+func (sl *SlogLogger) Info(message string) {
+	sl.log(LevelInfo, message)
+}
+
+func (sl *SlogLogger) Error(message string) {
+	sl.log(LevelError, message)
+}
+
+func (sl *SlogLogger) Enabled(level LogLevel) bool {
+	return sl.level.Level(sl.name).allows(level) &&
+		sl.handler.Enabled(context.Background(), toSlogLevel(level))
+}
+
+func (sl *SlogLogger) log(level LogLevel, message string) {
+	if !sl.Enabled(level) {
+		return
+	}
+
+	sl.handler.Handle(context.Background(), slog.NewRecord(time.Now(), toSlogLevel(level), message, 0))
+}
@@ -0,0 +1,104 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestSlogHandler(t *testing.T) {
+	message := "message"
+	out := log.NewTestOut()
+	logger := slog.New(log.NewSlogHandler(log.NewTestLogger(out)))
+
+	for _, tc := range []struct {
+		name  string
+		level slog.Level
+		exp   log.LogLevel
+	}{
+		{
+			name:  "debug",
+			level: slog.LevelDebug,
+			exp:   log.LevelDebug,
+		},
+		{
+			name:  "info",
+			level: slog.LevelInfo,
+			exp:   log.LevelInfo,
+		},
+		{
+			name:  "warn",
+			level: slog.LevelWarn,
+			exp:   log.LevelInfo,
+		},
+		{
+			name:  "error",
+			level: slog.LevelError,
+			exp:   log.LevelError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out.Flush()
+			logger.Log(context.Background(), tc.level, message)
+
+			test.Equals(t, 1, len(out.Lines))
+			test.Equals(t, tc.exp, out.Lines[0].Level)
+			test.Equals(t, message, out.Lines[0].Message)
+		})
+	}
+}
+
+func TestSlogHandlerAttrs(t *testing.T) {
+	out := log.NewTestOut()
+	logger := slog.New(log.NewSlogHandler(log.NewTestLogger(out)))
+
+	logger.With("service", "api").
+		WithGroup("http").
+		With("method", "GET").
+		Info("message", "status", 200, slog.Group("req", "path", "/"))
+
+	test.Equals(t, 1, len(out.Lines))
+	test.Equals(t, log.Fields{
+		"service":       "api",
+		"http.method":   "GET",
+		"http.status":   int64(200),
+		"http.req.path": "/",
+	}, out.Lines[0].Fields)
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := log.NewSlogLogger(handler)
+
+	logger.Debug("debug")
+	test.Equals(t, 0, buf.Len())
+
+	logger.WithField("key", "value").Error("error")
+	line := map[string]interface{}{}
+	test.OK(t, json.Unmarshal(buf.Bytes(), &line))
+	test.IncludesMap(t, map[string]interface{}{
+		"level": "ERROR",
+		"msg":   "error",
+		"key":   "value",
+	}, line)
+
+	buf.Reset()
+	logger.SetLogLevel(log.LevelError)
+	logger.Info("info")
+	test.Equals(t, 0, buf.Len())
+}
+
+func TestSlogLoggerWithGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := log.NewSlogLogger(slog.NewJSONHandler(buf, nil))
+
+	logger.WithGroup("http").WithField("method", "GET").Info("message")
+
+	test.Includes(t, `"http":{"method":"GET"}`, buf.String())
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	stdlog "log"
+	"regexp"
+)
+
+const sourceKey = "source"
+
+// stdPrefix matches the date, time and file prefixes that the standard
+// logger can add to a line.
+var stdPrefix = regexp.MustCompile(`^(?:\d{4}/\d{2}/\d{2} )?(?:\d{2}:\d{2}:\d{2}(?:\.\d+)? )?(?:([^\s:]+\.go:\d+): )?`)
+
+type stdWriter struct {
+	logger Logger
+	level  LogLevel
+}
+
+// NewStdWriter returns an io.Writer that logs every write as one line at
+// the given level. The prefixes of the standard logger are removed, the file
+// and line it adds with the Lshortfile or Llongfile flags are logged as
+// "source".
+func NewStdWriter(logger Logger, level LogLevel) io.Writer {
+	return &stdWriter{
+		logger: logger,
+		level:  level,
+	}
+}
+
+func (sw *stdWriter) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\r\n")
+	logger := sw.logger
+	if m := stdPrefix.FindSubmatchIndex(line); m != nil {
+		if m[2] >= 0 {
+			logger = logger.WithField(sourceKey, string(line[m[2]:m[3]]))
+		}
+		line = line[m[1]:]
+	}
+	logAt(logger, sw.level, string(line))
+
+	return len(p), nil
+}
+
+// NewStdLogger returns a standard library logger that writes to logger,
+// for instance for http.Server.ErrorLog.
+func NewStdLogger(logger Logger, level LogLevel) *stdlog.Logger {
+	return stdlog.New(NewStdWriter(logger, level), "", stdlog.Lshortfile)
+}
+
+// RedirectStdLog makes the global standard logger write to logger. The
+// returned function restores the previous output, prefix and flags.
+func RedirectStdLog(logger Logger, level LogLevel) func() {
+	out, prefix, flags := stdlog.Writer(), stdlog.Prefix(), stdlog.Flags()
+	stdlog.SetOutput(NewStdWriter(logger, level))
+	stdlog.SetPrefix("")
+	stdlog.SetFlags(stdlog.Lshortfile)
+
+	return func() {
+		stdlog.SetOutput(out)
+		stdlog.SetPrefix(prefix)
+		stdlog.SetFlags(flags)
+	}
+}
@@ -0,0 +1,92 @@
+package log_test
+
+import (
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestStdWriter(t *testing.T) {
+	out := log.NewTestOut()
+	w := log.NewStdWriter(log.NewTestLogger(out), log.LevelInfo)
+
+	for _, tc := range []struct {
+		name   string
+		flags  int
+		exp    string
+		source bool
+	}{
+		{
+			name: "plain",
+			exp:  "message",
+		},
+		{
+			name:  "date and time",
+			flags: stdlog.LstdFlags | stdlog.Lmicroseconds,
+			exp:   "message",
+		},
+		{
+			name:   "short file",
+			flags:  stdlog.LstdFlags | stdlog.Lshortfile,
+			exp:    "message",
+			source: true,
+		},
+		{
+			name:   "long file",
+			flags:  stdlog.Llongfile,
+			exp:    "message",
+			source: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out.Flush()
+			stdlog.New(w, "", tc.flags).Print("message")
+
+			test.Equals(t, 1, len(out.Lines))
+			test.Equals(t, log.LevelInfo, out.Lines[0].Level)
+			test.Equals(t, tc.exp, out.Lines[0].Message)
+			source, ok := out.Lines[0].Fields["source"].(string)
+			test.Equals(t, tc.source, ok)
+			if tc.source {
+				test.Includes(t, "stdlog_test.go:", source)
+			}
+		})
+	}
+}
+
+func TestStdLoggerHTTPServer(t *testing.T) {
+	out := log.NewTestOut()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	srv.Config.ErrorLog = log.NewStdLogger(log.NewTestLogger(out), log.LevelError)
+	srv.Start()
+	defer srv.Close()
+
+	http.Get(srv.URL)
+	srv.Close()
+
+	test.Assert(t, len(out.Lines) > 0, "expected a line")
+	test.Equals(t, log.LevelError, out.Lines[0].Level)
+	test.Includes(t, "http: panic serving", out.Lines[0].Message)
+	test.Includes(t, "boom", out.Lines[0].Message)
+	test.NotZero(t, out.Lines[0].Fields["source"])
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	out := log.NewTestOut()
+	flags := stdlog.Flags()
+	restore := log.RedirectStdLog(log.NewTestLogger(out), log.LevelInfo)
+
+	stdlog.Printf("message %d", 1)
+	restore()
+
+	test.Equals(t, 1, len(out.Lines))
+	test.Equals(t, "message 1", out.Lines[0].Message)
+	test.Includes(t, "stdlog_test.go:", out.Lines[0].Fields["source"].(string))
+	test.Equals(t, flags, stdlog.Flags())
+}
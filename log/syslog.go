@@ -0,0 +1,300 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FacilityUser   = SyslogFacility(1)
+	FacilityDaemon = SyslogFacility(3)
+	FacilityLocal0 = SyslogFacility(16)
+	FacilityLocal1 = SyslogFacility(17)
+	FacilityLocal2 = SyslogFacility(18)
+	FacilityLocal3 = SyslogFacility(19)
+	FacilityLocal4 = SyslogFacility(20)
+	FacilityLocal5 = SyslogFacility(21)
+	FacilityLocal6 = SyslogFacility(22)
+	FacilityLocal7 = SyslogFacility(23)
+
+	// syslogSDID is the id of the structured data element that holds the
+	// fields. 32473 is the enterprise number reserved for documentation.
+	syslogSDID          = "fields@32473"
+	syslogTimeFormat    = "2006-01-02T15:04:05.000000Z07:00"
+	syslogMaxNameLength = 32
+)
+
+var ErrSyslogUnsupportedNetwork = errors.New("unsupported syslog network")
+
+type SyslogFacility int
+
+// SyslogConfig configures the header of FormatSyslog messages. Facility
+// defaults to FacilityUser, AppName to the name of the executable and
+// Hostname to the name of the host.
+type SyslogConfig struct {
+	Facility SyslogFacility
+	AppName  string
+	Hostname string
+}
+
+// WithSyslog sets the header fields for FormatSyslog.
+func WithSyslog(config SyslogConfig) Option {
+	return func(o *options) {
+		o.syslog = config
+	}
+}
+
+// syslogEncoder writes RFC 5424 messages, one per line, with line breaks in
+// the message and the values escaped. The fields are written as parameters
+// of a single structured data element.
+type syslogEncoder struct {
+	facility SyslogFacility
+	header   string
+}
+
+func newSyslogEncoder(config SyslogConfig) *syslogEncoder {
+	if config.Facility == 0 {
+		config.Facility = FacilityUser
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+
+	return &syslogEncoder{
+		facility: config.Facility,
+		header: fmt.Sprintf("%s %s %d -",
+			syslogHeaderField(config.Hostname, 255),
+			syslogHeaderField(config.AppName, 48),
+			os.Getpid(),
+		),
+	}
+}
+
+func (se *syslogEncoder) appendField(buf []byte, key string, value interface{}) []byte {
+	buf = append(buf, ' ')
+	buf = append(buf, syslogParamName(key)...)
+	buf = append(buf, '=', '"')
+	buf = appendSyslogParamValue(buf, value)
+
+	return append(buf, '"')
+}
+
+func (se *syslogEncoder) appendLine(buf []byte, e entry) []byte {
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(int(se.facility)*8+syslogSeverity(e.level)), 10)
+	buf = append(buf, '>', '1', ' ')
+	if e.time.IsZero() {
+		buf = append(buf, '-')
+	} else {
+		buf = e.time.AppendFormat(buf, syslogTimeFormat)
+	}
+	buf = append(buf, ' ')
+	buf = append(buf, se.header...)
+	buf = append(buf, ' ')
+	if len(e.extra) == 0 && len(e.fields) == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = append(buf, '[')
+		buf = append(buf, syslogSDID...)
+		buf = append(buf, e.extra...)
+		buf = append(buf, e.fields...)
+		buf = append(buf, ']')
+	}
+	buf = append(buf, ' ')
+	buf = appendSyslogLine(buf, e.message)
+
+	return append(buf, '\n')
+}
+
+func syslogSeverity(level LogLevel) int {
+	switch level {
+	case LevelDebug:
+		return 7
+	case LevelError:
+		return 3
+	default:
+		return 6
+	}
+}
+
+// syslogHeaderField replaces characters that are not allowed in a header
+// field and limits its length.
+func syslogHeaderField(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+
+	return s
+}
+
+func syslogParamName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "_"
+	}
+	if len(s) > syslogMaxNameLength {
+		s = s[:syslogMaxNameLength]
+	}
+
+	return s
+}
+
+func appendSyslogParamValue(buf []byte, value interface{}) []byte {
+	s, ok := textValue(value)
+	if !ok {
+		s = "<nil>"
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', '"', ']':
+			buf = append(buf, '\\', s[i])
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		default:
+			buf = append(buf, s[i])
+		}
+	}
+
+	return buf
+}
+
+// appendSyslogLine appends s with line breaks escaped as \n and \r, so that
+// every message stays on one line.
+func appendSyslogLine(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		default:
+			buf = append(buf, s[i])
+		}
+	}
+
+	return buf
+}
+
+// SyslogWriter is an io.Writer that sends every write as a syslog message
+// to a server, for use with FormatSyslog. A trailing newline is removed.
+// Over TCP, and unix stream sockets, messages are framed with octet
+// counting as described in RFC 6587. A failed write is retried once over a
+// new connection.
+type SyslogWriter struct {
+	mu      sync.Mutex
+	network string
+	address string
+	conn    net.Conn
+	framed  bool
+}
+
+// DialSyslog connects to a syslog server. The network is "udp", "tcp" or
+// "unix". For "unix" a datagram socket is tried first, then a stream.
+func DialSyslog(network, address string) (*SyslogWriter, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrSyslogUnsupportedNetwork, network)
+	}
+
+	sw := &SyslogWriter{
+		network: network,
+		address: address,
+	}
+	if err := sw.connect(); err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+func (sw *SyslogWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+	err := sw.send(msg)
+	if err != nil {
+		if sw.conn != nil {
+			sw.conn.Close()
+			sw.conn = nil
+		}
+		err = sw.send(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (sw *SyslogWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.conn == nil {
+		return nil
+	}
+	err := sw.conn.Close()
+	sw.conn = nil
+
+	return err
+}
+
+func (sw *SyslogWriter) send(msg []byte) error {
+	if sw.conn == nil {
+		if err := sw.connect(); err != nil {
+			return err
+		}
+	}
+	if sw.framed {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	_, err := sw.conn.Write(msg)
+
+	return err
+}
+
+func (sw *SyslogWriter) connect() error {
+	if sw.network == "unix" {
+		if conn, err := net.Dial("unixgram", sw.address); err == nil {
+			sw.conn, sw.framed = conn, false
+			return nil
+		}
+	}
+	conn, err := net.DialTimeout(sw.network, sw.address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	sw.conn = conn
+	sw.framed = !strings.HasPrefix(sw.network, "udp")
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package log_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestSyslogFormat(t *testing.T) {
+	tw := &testWriter{}
+	logger := log.NewGoKitIOLogger(tw,
+		log.WithFormat(log.FormatSyslog),
+		log.WithSyslog(log.SyslogConfig{
+			Facility: log.FacilityLocal0,
+			AppName:  "my app",
+			Hostname: "host",
+		}),
+	)
+	logger.SetLogLevel(log.LevelDebug)
+
+	for _, tc := range []struct {
+		name string
+		log  func(log.Logger)
+		exp  string
+	}{
+		{
+			name: "debug without fields",
+			log:  func(l log.Logger) { l.Debug("message") },
+			exp:  `<135>1 \S+ host my_app \d+ - - message`,
+		},
+		{
+			name: "info with fields",
+			log: func(l log.Logger) {
+				l.With(log.Fields{"key": "value", "quoted": `a "b" [c]`, "bad key=": 1}).Info("message")
+			},
+			exp: `<134>1 \S+ host my_app \d+ - \[fields@32473 bad_key_="1" key="value" quoted="a \\"b\\" \[c\\]"\] message`,
+		},
+		{
+			name: "error",
+			log:  func(l log.Logger) { l.WithErr(errors.New("failed")).Error("message") },
+			exp:  `<131>1 \S+ host my_app \d+ - \[fields@32473 error="failed" error.type="\*errors.errorString"\] message`,
+		},
+		{
+			name: "line breaks",
+			log: func(l log.Logger) {
+				l.WithField("stack", "a\nb").Info("syslog\r\nmultiline")
+			},
+			exp: `<134>1 \S+ host my_app \d+ - \[fields@32473 stack="a\\nb"\] syslog\\r\\nmultiline`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw.Flush()
+			tc.log(logger)
+
+			test.Equals(t, 1, len(tw.LogLines))
+			exp := regexp.MustCompile("^" + tc.exp + "\n$")
+			test.Assert(t, exp.MatchString(tw.LogLines[0]), "unexpected line", tw.LogLines[0])
+		})
+	}
+}
+
+func TestSyslogWriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	test.OK(t, err)
+	defer conn.Close()
+
+	sw, err := log.DialSyslog("udp", conn.LocalAddr().String())
+	test.OK(t, err)
+	defer sw.Close()
+	logger := log.NewGoKitIOLogger(sw, log.WithFormat(log.FormatSyslog))
+
+	logger.Info("message")
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	test.OK(t, err)
+	msg := string(buf[:n])
+	test.Assert(t, strings.HasPrefix(msg, "<14>1 "), "unexpected message", msg)
+	test.Assert(t, strings.HasSuffix(msg, " - - message"), "unexpected message", msg)
+	test.Includes(t, fmt.Sprintf(" %d ", os.Getpid()), msg)
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	test.OK(t, err)
+	defer ln.Close()
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					length, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, err := strconv.Atoi(strings.TrimSpace(length))
+					if err != nil {
+						return
+					}
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					messages <- string(msg)
+					if strings.HasSuffix(string(msg), "disconnect") {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	sw, err := log.DialSyslog("tcp", ln.Addr().String())
+	test.OK(t, err)
+	defer sw.Close()
+	logger := log.NewGoKitIOLogger(sw, log.WithFormat(log.FormatSyslog))
+
+	logger.Info("first\nline")
+	logger.Error("disconnect")
+	for _, exp := range []string{`first\nline`, "disconnect"} {
+		select {
+		case msg := <-messages:
+			test.Assert(t, strings.HasSuffix(msg, " "+exp), "unexpected message", msg)
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+		}
+	}
+
+	// the server closed the connection, the writer should reconnect
+	deadline := time.After(2 * time.Second)
+	for {
+		logger.Info("reconnected")
+		select {
+		case msg := <-messages:
+			test.Assert(t, strings.HasSuffix(msg, " reconnected"), "unexpected message", msg)
+			return
+		case <-deadline:
+			t.Fatal("writer did not reconnect")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestDialSyslogUnsupportedNetwork(t *testing.T) {
+	_, err := log.DialSyslog("http", "localhost:514")
+	test.Assert(t, errors.Is(err, log.ErrSyslogUnsupportedNetwork), "unexpected error", err)
+}
@@ -0,0 +1,104 @@
+package log
+
+// Sink is an output of a Tee. Lines below Level are not passed to Logger.
+// Level is fixed, the level of Logger itself applies as well and can be
+// changed through the Tee.
+type Sink struct {
+	Logger Logger
+	Level  LogLevel
+}
+
+// Tee is a Logger that writes every line to multiple sinks, each with their
+// own threshold and, when the sink loggers are created with different
+// options, their own format.
+type Tee struct {
+	sinks []Sink
+}
+
+func NewTee(sinks ...Sink) Logger {
+	return &Tee{
+		sinks: sinks,
+	}
+}
+
+// SetLogLevel changes the level of the loggers of all sinks.
+func (t *Tee) SetLogLevel(loglevel LogLevel) {
+	for _, s := range t.sinks {
+		s.Logger.SetLogLevel(loglevel)
+	}
+}
+
+func (t *Tee) Named(name string) Logger {
+	return t.derive(func(logger Logger) Logger {
+		return logger.Named(name)
+	})
+}
+
+func (t *Tee) WithGroup(name string) Logger {
+	return t.derive(func(logger Logger) Logger {
+		return logger.WithGroup(name)
+	})
+}
+
+func (t *Tee) WithField(key string, value interface{}) Logger {
+	return t.derive(func(logger Logger) Logger {
+		return logger.WithField(key, value)
+	})
+}
+
+func (t *Tee) WithErr(err error) Logger {
+	return t.derive(func(logger Logger) Logger {
+		return logger.WithErr(err)
+	})
+}
+
+func (t *Tee) With(fields Fields) Logger {
+	return t.derive(func(logger Logger) Logger {
+		return logger.With(fields)
+	})
+}
+
+func (t *Tee) Debug(message string) {
+	t.log(LevelDebug, message)
+}
+
+func (t *Tee) Info(message string) {
+	t.log(LevelInfo, message)
+}
+
+func (t *Tee) Error(message string) {
+	t.log(LevelError, message)
+}
+
+// Enabled reports whether at least one sink accepts lines at level.
+func (t *Tee) Enabled(level LogLevel) bool {
+	for _, s := range t.sinks {
+		if (s.Level == "" || s.Level.allows(level)) && s.Logger.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Tee) derive(f func(Logger) Logger) Logger {
+	sinks := make([]Sink, len(t.sinks))
+	for i, s := range t.sinks {
+		sinks[i] = Sink{
+			Logger: f(s.Logger),
+			Level:  s.Level,
+		}
+	}
+
+	return &Tee{
+		sinks: sinks,
+	}
+}
+
+func (t *Tee) log(level LogLevel, message string) {
+	for _, s := range t.sinks {
+		if s.Level == "" || s.Level.allows(level) {
+			logAt(s.Logger, level, message)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package log_test
+
+import (
+	"errors"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+func TestTee(t *testing.T) {
+	jsonOut, logfmtOut, testOut := &testWriter{}, &testWriter{}, log.NewTestOut()
+	jsonLogger := log.NewGoKitIOLogger(jsonOut)
+	logfmtLogger := log.NewGoKitIOLogger(logfmtOut, log.WithFormat(log.FormatLogfmt))
+	logger := log.NewTee(
+		log.Sink{Logger: jsonLogger, Level: log.LevelError},
+		log.Sink{Logger: logfmtLogger},
+		log.Sink{Logger: log.NewTestLogger(testOut), Level: log.LevelInfo},
+	)
+	logger.SetLogLevel(log.LevelDebug)
+
+	derived := logger.Named("db").WithField("key", "value").WithErr(errors.New("failed"))
+	derived.Debug("debug")
+	derived.Info("info")
+	derived.Error("error")
+
+	test.Equals(t, 1, len(jsonOut.LogLines))
+	test.Includes(t, `"level":"error"`, jsonOut.LogLines[0])
+	test.Includes(t, `"logger":"db","key":"value","error":{"message":"failed"`, jsonOut.LogLines[0])
+
+	test.Equals(t, 3, len(logfmtOut.LogLines))
+	for _, line := range logfmtOut.LogLines {
+		test.Includes(t, " logger=db key=value error=failed", line)
+	}
+
+	test.Equals(t, 2, len(testOut.Lines))
+	test.Equals(t, log.LevelInfo, testOut.Lines[0].Level)
+	test.Equals(t, log.LevelError, testOut.Lines[1].Level)
+}
@@ -26,6 +26,8 @@ func (to *TestOut) Flush() {
 
 type TestLogger struct {
 	fields Fields
+	name   string
+	group  string
 	level  LogLevel
 	out    *TestOut
 }
@@ -42,6 +44,37 @@ func (tl *TestLogger) SetLogLevel(level LogLevel) {
 	tl.level = level
 }
 
+func (tl *TestLogger) Named(name string) Logger {
+	fullName := joinName(tl.name, name)
+	newFields := make(Fields)
+	for k, v := range tl.fields {
+		newFields[k] = v
+	}
+	newFields[nameKey] = fullName
+
+	return &TestLogger{
+		fields: newFields,
+		name:   fullName,
+		group:  tl.group,
+		level:  tl.level,
+		out:    tl.out,
+	}
+}
+
+func (tl *TestLogger) WithGroup(name string) Logger {
+	if name == "" {
+		return tl
+	}
+
+	return &TestLogger{
+		fields: tl.fields,
+		name:   tl.name,
+		group:  tl.group + name + ".",
+		level:  tl.level,
+		out:    tl.out,
+	}
+}
+
 func (tl *TestLogger) WithField(key string, value interface{}) Logger {
 	return tl.With(Fields{key: value})
 }
@@ -56,21 +89,28 @@ func (tl *TestLogger) With(fields Fields) Logger {
 		newFields[k] = v
 	}
 	for k, v := range fields {
-		newFields[k] = v
+		newFields[tl.group+k] = v
 	}
 
 	return &TestLogger{
 		fields: newFields,
+		name:   tl.name,
+		group:  tl.group,
 		level:  tl.level,
 		out:    tl.out,
 	}
 }
 
+// Enabled always returns true, the TestLogger records lines at every level.
+func (tl *TestLogger) Enabled(level LogLevel) bool {
+	return true
+}
+
 func (tl *TestLogger) Debug(message string) {
 	tl.out.Append(TestLine{
 		Level:   LevelDebug,
 		Message: message,
-		Fields:  tl.fields,
+		Fields:  resolveFields(tl.fields),
 	})
 
 	tl.fields = make(Fields)
@@ -80,7 +120,7 @@ func (tl *TestLogger) Info(message string) {
 	tl.out.Append(TestLine{
 		Level:   LevelInfo,
 		Message: message,
-		Fields:  tl.fields,
+		Fields:  resolveFields(tl.fields),
 	})
 
 	tl.fields = make(Fields)
@@ -90,7 +130,7 @@ func (tl *TestLogger) Error(message string) {
 	tl.out.Append(TestLine{
 		Level:   LevelError,
 		Message: message,
-		Fields:  tl.fields,
+		Fields:  resolveFields(tl.fields),
 	})
 
 	tl.fields = make(Fields)
@@ -100,3 +100,16 @@ func TestTestLoggerWith(t *testing.T) {
 		Message: message,
 	}, out.Lines[0])
 }
+
+func TestTestLoggerWithGroup(t *testing.T) {
+	out := log.NewTestOut()
+	logger := log.NewTestLogger(out).WithField("a", 1).WithGroup("g").WithField("b", 2).Named("n")
+	logger.Info("message")
+
+	test.Equals(t, 1, len(out.Lines))
+	test.Equals(t, log.Fields{
+		"a":      1,
+		"g.b":    2,
+		"logger": "n",
+	}, out.Lines[0].Fields)
+}
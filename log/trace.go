@@ -0,0 +1,224 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	TraceparentHeader = "Traceparent"
+	TracestateHeader  = "Tracestate"
+	traceIDKey        = "trace_id"
+	spanIDKey         = "span_id"
+
+	traceparentLength = 55
+	flagSampled       = 0x01
+)
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies a span within a trace, as carried by the W3C
+// traceparent header. TraceState is the tracestate header, which is passed
+// on as it is.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+// NewSpanContext starts a new trace with random IDs.
+func NewSpanContext() SpanContext {
+	return SpanContext{
+		TraceID: newTraceID(),
+		SpanID:  newSpanID(),
+	}
+}
+
+// ParseTraceparent parses a traceparent header value. Versions after 00
+// are accepted as far as they are compatible, as the specification asks.
+func ParseTraceparent(s string) (SpanContext, error) {
+	if len(s) < traceparentLength {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+	version, ok := parseHex(s[0:2])
+	if !ok || len(version) != 1 || version[0] == 0xff || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+	if version[0] == 0 && len(s) != traceparentLength {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+	if len(s) > traceparentLength && s[traceparentLength] != '-' {
+		// later versions may only add fields
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+
+	var sc SpanContext
+	traceID, ok := parseHex(s[3:35])
+	if !ok {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+	copy(sc.TraceID[:], traceID)
+	spanID, ok := parseHex(s[36:52])
+	if !ok {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+	copy(sc.SpanID[:], spanID)
+	flags, ok := parseHex(s[53:55])
+	if !ok {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, s)
+	}
+
+	return sc, nil
+}
+
+// parseHex only accepts lowercase hex digits, uppercase is not allowed in
+// traceparent.
+func parseHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+
+	return b, err == nil
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+// Traceparent returns the value for the traceparent header, in version 00.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// NewChild returns a span context for a new span in the same trace.
+func (sc SpanContext) NewChild() SpanContext {
+	child := sc
+	child.SpanID = newSpanID()
+
+	return child
+}
+
+// ContextWithSpan returns a copy of ctx that carries sc. Loggers from
+// FromContext get its IDs as the fields trace_id and span_id.
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey, sc)
+}
+
+// SpanFromContext returns the span context stored in ctx.
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanKey).(SpanContext)
+
+	return sc, ok
+}
+
+func traceFields(sc SpanContext) Fields {
+	return Fields{
+		traceIDKey: sc.TraceID.String(),
+		spanIDKey:  sc.SpanID.String(),
+	}
+}
+
+// spanFromRequest continues the trace of the traceparent header of r in a
+// new span, or starts a new trace if there is no valid header.
+func spanFromRequest(r *http.Request) SpanContext {
+	parent, err := ParseTraceparent(r.Header.Get(TraceparentHeader))
+	if err != nil {
+		return NewSpanContext()
+	}
+	child := parent.NewChild()
+	child.TraceState = r.Header.Get(TracestateHeader)
+
+	return child
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+
+	return id
+}
+
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+// NewTraceTransport returns an http.RoundTripper that sets the traceparent
+// and tracestate headers on outgoing requests from the span context of the
+// request context, so that the called service continues the trace. Requests
+// that already have a traceparent header are left alone. If base is nil,
+// http.DefaultTransport is used.
+func NewTraceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &traceTransport{
+		base: base,
+	}
+}
+
+func (tt *traceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	sc, ok := SpanFromContext(r.Context())
+	if !ok || !sc.IsValid() || r.Header.Get(TraceparentHeader) != "" {
+		return tt.base.RoundTrip(r)
+	}
+
+	// a RoundTripper must not modify the request
+	r = r.Clone(r.Context())
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.Header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		r.Header.Set(TracestateHeader, sc.TraceState)
+	}
+
+	return tt.base.RoundTrip(r)
+}
@@ -0,0 +1,185 @@
+package log_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-mod.ewintr.nl/go-kit/log"
+	"go-mod.ewintr.nl/go-kit/test"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header string
+		expErr bool
+	}{
+		{name: "valid", header: testTraceparent},
+		{name: "not sampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "future version", header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "empty", header: "", expErr: true},
+		{name: "too long", header: testTraceparent + "-extra", expErr: true},
+		{name: "invalid version", header: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expErr: true},
+		{name: "future version suffix", header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01extra", expErr: true},
+		{name: "uppercase", header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", expErr: true},
+		{name: "zero trace id", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expErr: true},
+		{name: "zero span id", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", expErr: true},
+		{name: "separator", header: "00-4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7-01", expErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sc, err := log.ParseTraceparent(tc.header)
+			if tc.expErr {
+				test.Assert(t, errors.Is(err, log.ErrInvalidTraceparent), "expected ErrInvalidTraceparent, got %v", err)
+				return
+			}
+			test.OK(t, err)
+			test.Equals(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			test.Equals(t, "00f067aa0ba902b7", sc.SpanID.String())
+		})
+	}
+
+	sc, err := log.ParseTraceparent(testTraceparent)
+	test.OK(t, err)
+	test.Assert(t, sc.IsSampled(), "expected sampled")
+	test.Equals(t, testTraceparent, sc.Traceparent())
+}
+
+func TestSpanContext(t *testing.T) {
+	sc := log.NewSpanContext()
+	test.Assert(t, sc.IsValid(), "expected valid span context")
+	parsed, err := log.ParseTraceparent(sc.Traceparent())
+	test.OK(t, err)
+	test.Equals(t, sc, parsed)
+
+	child := sc.NewChild()
+	test.Equals(t, sc.TraceID, child.TraceID)
+	test.Assert(t, sc.SpanID != child.SpanID, "expected new span id")
+}
+
+func TestContextWithSpan(t *testing.T) {
+	out := log.NewTestOut()
+	sc, err := log.ParseTraceparent(testTraceparent)
+	test.OK(t, err)
+
+	_, ok := log.SpanFromContext(context.Background())
+	test.Assert(t, !ok, "expected no span context")
+
+	ctx := log.NewContext(context.Background(), log.NewTestLogger(out))
+	ctx = log.ContextWithSpan(log.ContextWithField(ctx, "a", 1), sc)
+	stored, ok := log.SpanFromContext(ctx)
+	test.Assert(t, ok, "expected span context")
+	test.Equals(t, sc, stored)
+
+	log.FromContext(ctx).Info("message")
+	test.Equals(t, []log.TestLine{{
+		Level:   log.LevelInfo,
+		Message: "message",
+		Fields: log.Fields{
+			"a":        1,
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+		},
+	}}, out.Lines)
+}
+
+func TestTraceTransport(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: log.NewTraceTransport(nil)}
+
+	sc, err := log.ParseTraceparent(testTraceparent)
+	test.OK(t, err)
+	sc.TraceState = "vendor=value"
+
+	for _, tc := range []struct {
+		name      string
+		ctx       context.Context
+		header    string
+		expParent string
+		expState  string
+	}{
+		{
+			name: "no span",
+			ctx:  context.Background(),
+		},
+		{
+			name:      "span",
+			ctx:       log.ContextWithSpan(context.Background(), sc),
+			expParent: testTraceparent,
+			expState:  "vendor=value",
+		},
+		{
+			name:      "existing header",
+			ctx:       log.ContextWithSpan(context.Background(), sc),
+			header:    "00-11111111111111111111111111111111-2222222222222222-00",
+			expParent: "00-11111111111111111111111111111111-2222222222222222-00",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(tc.ctx, http.MethodGet, srv.URL, nil)
+			test.OK(t, err)
+			if tc.header != "" {
+				req.Header.Set(log.TraceparentHeader, tc.header)
+			}
+			res, err := client.Do(req)
+			test.OK(t, err)
+			res.Body.Close()
+
+			h := <-headers
+			test.Equals(t, tc.expParent, h.Get(log.TraceparentHeader))
+			test.Equals(t, tc.expState, h.Get(log.TracestateHeader))
+			test.Equals(t, tc.header, req.Header.Get(log.TraceparentHeader))
+		})
+	}
+}
+
+func TestHTTPMiddlewareTrace(t *testing.T) {
+	tw := &testWriter{}
+	var sc log.SpanContext
+	handler := log.HTTPMiddleware(log.NewGoKitIOLogger(tw), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, _ = log.SpanFromContext(r.Context())
+		log.FromContext(r.Context()).Info("handling")
+	}))
+
+	t.Run("continue", func(t *testing.T) {
+		tw.Flush()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(log.TraceparentHeader, testTraceparent)
+		req.Header.Set(log.TracestateHeader, "vendor=value")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		test.Equals(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		test.Assert(t, sc.SpanID.String() != "00f067aa0ba902b7", "expected a new span")
+		test.Assert(t, sc.IsSampled(), "expected sampled flag to be kept")
+		test.Equals(t, "vendor=value", sc.TraceState)
+		test.Equals(t, 2, len(tw.LogLines))
+		for _, line := range tw.LogLines {
+			fields := map[string]interface{}{}
+			test.OK(t, json.Unmarshal([]byte(line), &fields))
+			test.IncludesMap(t, map[string]interface{}{
+				"trace_id": sc.TraceID.String(),
+				"span_id":  sc.SpanID.String(),
+			}, fields)
+		}
+	})
+
+	t.Run("new trace", func(t *testing.T) {
+		tw.Flush()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(log.TraceparentHeader, "invalid")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		test.Assert(t, sc.IsValid(), "expected a new trace")
+		test.Includes(t, `"trace_id":"`+sc.TraceID.String()+`"`, strings.Join(tw.LogLines, "\n"))
+	})
+}